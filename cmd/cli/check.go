@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+	"gopkg.in/yaml.v2"
+)
+
+func checkFlags(fs *flag.FlagSet, values *inputValues) {
+	imageFlags(fs, values)
+	fs.StringVar(&values.allowList, "allow", "", "")
+	fs.StringVar(&values.denyList, "deny", "", "")
+}
+
+// runCheck checks the syscalls of the files in values against the allow-list and deny-list
+// provided, returning exit code 1 when any syscall is not permitted or any file could not be extracted.
+func runCheck(stdOut io.Writer, values inputValues, extract extractFunc) (int, error) {
+	policy, err := getPolicy(values)
+	if err != nil {
+		return 1, err
+	}
+
+	results, failed, err := extractFiles(values, extract)
+	if err != nil {
+		return 1, err
+	}
+
+	total := 0
+	for _, r := range results {
+		for _, v := range policy.Check(r.result.Syscalls) {
+			if total > 0 {
+				printf(stdOut, "\n")
+			}
+			total++
+			if len(results) > 1 {
+				printf(stdOut, "%s: ", r.fileName)
+			}
+
+			reason := "is not allowed"
+			if v.Denied {
+				reason = "is denied"
+			}
+			printf(stdOut, "%s (%d) %s:\n", v.Syscall.Name, v.Syscall.ID, reason)
+			writePath(stdOut, v.Syscall.CallPath)
+		}
+	}
+
+	if total == 0 {
+		printf(stdOut, "no disallowed system calls were found\n")
+		return exitCodeOf(failed), nil
+	}
+
+	printf(stdOut, "\n%d disallowed system calls found\n", total)
+	return 1, nil
+}
+
+// getPolicy returns the policy defined by the --allow and --deny files,
+// with systract.DefaultDenyList unless --deny is provided.
+func getPolicy(values inputValues) (systract.Policy, error) {
+	policy := systract.Policy{Denied: systract.DefaultDenyList}
+
+	if values.allowList != "" {
+		allowed, err := readSyscallList(values.allowList, true)
+		if err != nil {
+			return policy, err
+		}
+		policy.Allowed = allowed
+	}
+
+	if values.denyList != "" {
+		denied, err := readSyscallList(values.denyList, false)
+		if err != nil {
+			return policy, err
+		}
+		policy.Denied = denied
+	}
+
+	return policy, nil
+}
+
+// syscallList is the schema shared by seccomp profiles and json/yaml reports. The syscalls of
+// seccomp profiles are groups of names sharing an action, whilst the ones of reports have a name.
+type syscallList struct {
+	DefaultAction string `json:"defaultAction" yaml:"defaultAction"`
+	Syscalls      []struct {
+		Name   string   `json:"name" yaml:"name"`
+		Names  []string `json:"names" yaml:"names"`
+		Action string   `json:"action" yaml:"action"`
+	} `json:"syscalls" yaml:"syscalls"`
+}
+
+// readSyscallList reads the names of the syscalls listed in filePath, which is either
+// a seccomp profile, of which only the allowed syscalls are returned, a json or yaml report,
+// or a plain list of names separated by whitespace, in which # starts a comment.
+// Seccomp profiles are rejected unless allowSeccomp is set, as they list the syscalls allowed.
+func readSyscallList(filePath string, allowSeccomp bool) ([]string, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read syscall list: %s", err)
+	}
+
+	var list syscallList
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("could not parse syscall list %s: %s", filePath, err)
+		}
+	} else {
+		// Plain lists are yaml documents holding a single string, or none
+		// when they only have comments.
+		var document interface{}
+		if err := yaml.Unmarshal(data, &document); err != nil {
+			return nil, fmt.Errorf("could not parse syscall list %s: %s", filePath, err)
+		}
+		if _, ok := document.(string); ok || document == nil {
+			return parsePlainSyscallList(data), nil
+		}
+
+		if err := yaml.Unmarshal(data, &list); err != nil {
+			return nil, fmt.Errorf("could not parse syscall list %s: %s", filePath, err)
+		}
+	}
+
+	if list.Syscalls == nil {
+		return nil, fmt.Errorf("could not parse syscall list %s: no syscalls field found", filePath)
+	}
+	if list.DefaultAction != "" && !allowSeccomp {
+		return nil, fmt.Errorf("seccomp profile %s lists the syscalls allowed, so it cannot be used as a deny-list", filePath)
+	}
+
+	names := make([]string, 0, len(list.Syscalls))
+	for _, s := range list.Syscalls {
+		if s.Name != "" {
+			names = append(names, s.Name)
+		}
+		if s.Action == systract.SeccompActionAllow {
+			names = append(names, s.Names...)
+		}
+	}
+
+	return names, nil
+}
+
+func parsePlainSyscallList(data []byte) []string {
+	names := make([]string, 0)
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		names = append(names, strings.Fields(line)...)
+	}
+
+	return names
+}
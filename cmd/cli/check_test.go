@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+// writeTempFile writes contents into a file within dir, returning its path.
+func writeTempFile(t *testing.T, dir, name, contents string) string {
+	path := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatalf("could not setup test properly, got error: %s", err)
+	}
+
+	return path
+}
+
+func TestRun_Check(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gosystract")
+	if err != nil {
+		t.Fatalf("could not setup test properly, got error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	allowList := writeTempFile(t, dir, "allowed", "write\nread\n")
+	denyList := writeTempFile(t, dir, "denied", "read\n")
+	profile := writeTempFile(t, dir, "profile", `{"defaultAction": "SCMP_ACT_ERRNO", "syscalls": []}`)
+
+	assertThat := func(assumption string, args []string, syscalls []systract.SystemCall,
+		expected string, expectedExitCode int) {
+		should := should.New(t)
+		var stdOut, stdErr bytes.Buffer
+		exitCode := 0
+
+		Run(&stdOut, &stdErr, args, func(source systract.SourceReader, opts ...systract.Option) (*systract.Result, error) {
+			return &systract.Result{Syscalls: syscalls}, nil
+		}, func(code int) {
+			exitCode = code
+		})
+
+		should.BeEqual(expectedExitCode, exitCode, assumption)
+		should.BeEqual(expected, stdOut.String(), assumption)
+	}
+
+	write := systract.SystemCall{ID: 1, Name: "write", CallPath: []string{"main.main"}}
+	read := systract.SystemCall{ID: 0, Name: "read", CallPath: []string{"main.main", "os.Read"}}
+	ptrace := systract.SystemCall{ID: 101, Name: "ptrace", CallPath: []string{"main.main", "syscall.PtraceAttach"}}
+	mount := systract.SystemCall{ID: 165, Name: "mount", CallPath: []string{"main.main", "syscall.Mount"}}
+
+	assertThat("should pass when all syscalls are allowed",
+		[]string{"gosystract", "check", "--allow", allowList, "filename"}, []systract.SystemCall{write, read},
+		"no disallowed system calls were found\n", 0)
+	assertThat("should list syscalls not allowed with their call paths",
+		[]string{"gosystract", "check", "--allow", allowList, "filename"}, []systract.SystemCall{write, mount},
+		"mount (165) is not allowed:\n    main.main\n    -> syscall.Mount\n\n1 disallowed system calls found\n", 1)
+	assertThat("should deny syscalls in the default deny-list",
+		[]string{"gosystract", "check", "filename"}, []systract.SystemCall{write, ptrace},
+		"ptrace (101) is denied:\n    main.main\n    -> syscall.PtraceAttach\n\n1 disallowed system calls found\n", 1)
+	assertThat("should replace the default deny-list",
+		[]string{"gosystract", "check", "--deny", denyList, "filename"}, []systract.SystemCall{ptrace, read},
+		"read (0) is denied:\n    main.main\n    -> os.Read\n\n1 disallowed system calls found\n", 1)
+	assertThat("should prefix violations with file names when multiple files are checked",
+		[]string{"gosystract", "check", "--allow", allowList, "-d", "file1", "file2"}, []systract.SystemCall{mount},
+		"file1: mount (165) is not allowed:\n    main.main\n    -> syscall.Mount\n\n"+
+			"file2: mount (165) is not allowed:\n    main.main\n    -> syscall.Mount\n\n2 disallowed system calls found\n", 1)
+	assertThat("should error when seccomp profile is used as deny-list",
+		[]string{"gosystract", "check", "--deny", profile, "filename"}, nil, "", 1)
+	assertThat("should error when allow-list cannot be read",
+		[]string{"gosystract", "check", "--allow", filepath.Join(dir, "missing"), "filename"}, nil, "", 1)
+}
+
+func TestReadSyscallList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gosystract")
+	if err != nil {
+		t.Fatalf("could not setup test properly, got error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	assertThat := func(assumption, contents string, allowSeccomp bool, expected []string, expectedErr bool) {
+		should := should.New(t)
+		path := writeTempFile(t, dir, "list", contents)
+
+		actual, err := readSyscallList(path, allowSeccomp)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	profile := `{
+  "defaultAction": "SCMP_ACT_ERRNO",
+  "syscalls": [
+    {"names": ["read", "write"], "action": "SCMP_ACT_ALLOW"},
+    {"names": ["ptrace"], "action": "SCMP_ACT_ERRNO"}
+  ]
+}`
+
+	assertThat("should read plain lists", "# allowed syscalls\nread\nwrite # stdout\n\nexit_group close\n", true,
+		[]string{"read", "write", "exit_group", "close"}, false)
+	assertThat("should read plain lists with comments only", "# no syscalls\n", true, []string{}, false)
+	assertThat("should only read allowed syscalls of seccomp profiles", profile, true, []string{"read", "write"}, false)
+	assertThat("should reject seccomp profiles when not allowed", profile, false, nil, true)
+	assertThat("should read json results", `{"metadata": {"path": "goapp"}, "syscalls": [{"id": 1, "name": "write"}]}`, false,
+		[]string{"write"}, false)
+	assertThat("should read empty json results", `{"metadata": {"path": "goapp"}, "syscalls": []}`, false,
+		[]string{}, false)
+	assertThat("should read yaml results", "metadata:\n  path: goapp\nsyscalls:\n- id: 0\n  name: read\n", false,
+		[]string{"read"}, false)
+	assertThat("should error for invalid json", `{"syscalls": [`, true, nil, true)
+	assertThat("should error for invalid yaml", "syscalls:\n- name: read\n  - write\n", true, nil, true)
+	assertThat("should error for yaml not listing syscalls", "metadata:\n  path: goapp\n", true, nil, true)
+	assertThat("should error for yaml with invalid syscalls", "syscalls: read\n", true, nil, true)
+}
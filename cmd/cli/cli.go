@@ -3,8 +3,10 @@ package cli
 
 import (
 	"errors"
+	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"strings"
 	"text/template"
 
@@ -17,12 +19,100 @@ var (
 
 	invalidSyntaxMessage string = "invalid syntax"
 
+	exeSource    string = "exe"
+	dumpSource   string = "dump"
+	nativeSource string = "native"
+
+	extractCommand string = "extract"
+	diffCommand    string = "diff"
+	profileCommand string = "profile"
+	graphCommand   string = "graph"
+	checkCommand   string = "check"
+	versionCommand string = "version"
+
 	usageMessage string = `Usage:
-gosystrac [flags] filePath
+gosystract [command] [flags] filePath...
+
+Commands:
+	extract	  Extracts the syscalls of a go executable (default).
+	diff	  Compares the syscalls of two go executables.
+	profile	  Generates a seccomp profile for a go executable.
+	graph	  Writes the call graph of a go executable (dot, graphml, json).
+	check	  Checks the syscalls of a go executable against an allow-list and a deny-list.
+	version	  Shows the version of gosystract.
+
+Use "gosystract [command] --help" for more information about a command.
+`
+
+	sourceFlagsUsage string = `	--dumpfile, -d    Handles a dump file instead of a go executable.
+	--native	  Disassembles the go executable without the go tools.
+	--arch		  Overrides the architecture detected from the file.
+	--cgo		  Includes the syscalls made through libc in cgo executables.
+	--progress	  Reports the progress of the disassembly parsing on stderr.
+	--jobs		  Defines the number of files and functions parsed concurrently (default: number of CPUs).
+	--sort		  Defines the order of the syscalls (discovery, id, name) (default: discovery).
+	--entry		  Defines a symbol or regular expression to walk from instead of main.main (repeatable).
+	--runtime	  Includes the syscalls made by the go runtime outside of the main call graph.
+	--indirect	  Defines how indirect calls are handled (conservative, direct) (default: conservative).
+	--strict	  Fails when syscalls may have been missed, e.g. when a syscall number cannot be resolved.
+`
+
+	imageFlagUsage string = `	--image		  Handles OCI image layouts or docker save tarballs, extracting their go executables.
+`
+
+	extractUsageMessage string = `Usage:
+gosystract extract [flags] filePath...
+
+Directories are searched for executables, or any file when --dumpfile is used.
+
+Flags:
+` + sourceFlagsUsage + imageFlagUsage + `	--template	  Defines a go template for the results.
+	--output	  Defines the output format (text, json, yaml, seccomp).
+	--explain	  Shows the call path that leads to the given syscall.
+	--by-package	  Groups the syscalls by the modules and packages that issue them.
+`
+
+	diffUsageMessage string = `Usage:
+gosystract diff [flags] oldFilePath newFilePath
+
+Exits with code 1 when syscalls were added.
+
+Flags:
+` + sourceFlagsUsage + `	--callpaths	  Shows the call paths of the syscalls added.
+`
+
+	profileUsageMessage string = `Usage:
+gosystract profile [flags] filePath...
+
+Directories are searched for executables, or any file when --dumpfile is used.
+Profiles always allow execve and the syscalls the go runtime needs to start.
+
+Flags:
+` + sourceFlagsUsage + imageFlagUsage
+
+	graphUsageMessage string = `Usage:
+gosystract graph [flags] filePath
+
+Flags:
+` + sourceFlagsUsage + `	--format	  Defines the format of the call graph (dot, graphml, json) (default: dot).
+	--prune		  Only keeps the paths from the entry points that lead to syscalls.
+	--by-package	  Collapses the call graph to package level.
+`
+
+	checkUsageMessage string = `Usage:
+gosystract check [flags] filePath...
+
+Exits with code 1 when syscalls that are not allowed, or that are denied, are found.
+The lists are files with one syscall per line, seccomp profiles, or json and yaml results.
+Seccomp profiles are only supported by --allow, as they list the syscalls allowed.
 
 Flags:
-	--dumpfile, -d    Handles a dump file instead of a go executable.
-	--template	  Defines a go template for the results.
+` + sourceFlagsUsage + imageFlagUsage + `	--allow		  Defines the file listing the syscalls allowed (default: all).
+	--deny		  Defines the file listing the syscalls denied (default: ptrace, kexec_load, init_module...).
+`
+
+	versionUsageMessage string = `Usage:
+gosystract version
 `
 
 	resultGoTemplate string = `{{if . -}}
@@ -34,101 +124,557 @@ Flags:
 `
 )
 
-func parseInputValues(args []string) (
-	inputIsDumpFile bool, customFormat string, fileName string, err error) {
+type extractFunc func(source systract.SourceReader, opts ...systract.Option) (*systract.Result, error)
+
+// command defines a gosystract subcommand, its flags and the number of files it expects.
+// Commands with multipleFiles expect at least one file.
+type command struct {
+	usage         string
+	files         int
+	multipleFiles bool
+	flags         func(fs *flag.FlagSet, values *inputValues)
+	run           func(stdOut io.Writer, values inputValues, extract extractFunc) (exitCode int, err error)
+}
+
+var commands = map[string]command{
+	extractCommand: {usage: extractUsageMessage, files: 1, multipleFiles: true, flags: extractFlags, run: runExtract},
+	diffCommand:    {usage: diffUsageMessage, files: 2, flags: diffFlags, run: runDiff},
+	profileCommand: {usage: profileUsageMessage, files: 1, multipleFiles: true, flags: imageFlags, run: runProfile},
+	graphCommand:   {usage: graphUsageMessage, files: 1, flags: graphFlags, run: runGraph},
+	checkCommand:   {usage: checkUsageMessage, files: 1, multipleFiles: true, flags: checkFlags, run: runCheck},
+	versionCommand: {usage: versionUsageMessage, files: 0, flags: func(*flag.FlagSet, *inputValues) {}, run: runVersion},
+}
+
+type inputValues struct {
+	command      string
+	sourceType   string
+	dumpFile     bool
+	native       bool
+	customFormat string
+	outputFormat string
+	arch         string
+	explain      string
+	byPackage    bool
+	cgo          bool
+	progress     bool
+	jobs         int
+	sort         systract.SortOrder
+	entryPoints  []string
+	runtime      bool
+	indirect     systract.IndirectCallMode
+	strict       bool
+	graphFormat  systract.GraphFormat
+	prune        bool
+	allowList    string
+	denyList     string
+	// errOutput is where warnings are reported to, and progress when it is enabled.
+	errOutput io.Writer
+	callPaths bool
+	image     bool
+	fileName  string
+	files     []string
+}
+
+func sourceFlags(fs *flag.FlagSet, values *inputValues) {
+	fs.BoolVar(&values.dumpFile, "dumpfile", false, "")
+	fs.BoolVar(&values.dumpFile, "d", false, "")
+	fs.BoolVar(&values.native, "native", false, "")
+	fs.StringVar(&values.arch, "arch", "", "")
+	fs.BoolVar(&values.cgo, "cgo", false, "")
+	fs.BoolVar(&values.progress, "progress", false, "")
+	fs.IntVar(&values.jobs, "jobs", 0, "")
+	fs.Var(&sortFlag{&values.sort}, "sort", "")
+	fs.Var(&listFlag{&values.entryPoints}, "entry", "")
+	fs.BoolVar(&values.runtime, "runtime", false, "")
+	fs.Var(&indirectFlag{&values.indirect}, "indirect", "")
+	fs.BoolVar(&values.strict, "strict", false, "")
+}
+
+// listFlag parses flags that can be provided multiple times.
+type listFlag struct {
+	values *[]string
+}
+
+func (f *listFlag) String() string {
+	if f.values == nil {
+		return ""
+	}
+	return strings.Join(*f.values, ",")
+}
+
+func (f *listFlag) Set(value string) error {
+	*f.values = append(*f.values, value)
+	return nil
+}
+
+// templateFlag parses the --template flag, removing the quotes around its value.
+type templateFlag struct {
+	format *string
+}
+
+func (f *templateFlag) String() string {
+	if f.format == nil {
+		return ""
+	}
+	return *f.format
+}
+
+func (f *templateFlag) Set(value string) error {
+	value = strings.TrimPrefix(value, "\"")
+	*f.format = strings.TrimSuffix(value, "\"")
+	return nil
+}
+
+// sortFlag parses the --sort flag, ensuring it holds one of the orders supported.
+type sortFlag struct {
+	order *systract.SortOrder
+}
+
+func (f *sortFlag) String() string {
+	if f.order == nil {
+		return ""
+	}
+	return string(*f.order)
+}
+
+func (f *sortFlag) Set(value string) error {
+	for _, order := range systract.SortOrders {
+		if value == string(order) {
+			*f.order = order
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid sort order: %s", value)
+}
+
+// indirectFlag parses the --indirect flag, ensuring it holds one of the modes supported.
+// The deprecated strict mode is still accepted, as an alias of direct.
+type indirectFlag struct {
+	mode *systract.IndirectCallMode
+}
+
+func (f *indirectFlag) String() string {
+	if f.mode == nil {
+		return ""
+	}
+	return string(*f.mode)
+}
 
+func (f *indirectFlag) Set(value string) error {
+	mode, err := systract.ParseIndirectCallMode(value)
+	if err != nil {
+		return err
+	}
+
+	*f.mode = mode
+	return nil
+}
+
+func imageFlags(fs *flag.FlagSet, values *inputValues) {
+	sourceFlags(fs, values)
+	fs.BoolVar(&values.image, "image", false, "")
+}
+
+func extractFlags(fs *flag.FlagSet, values *inputValues) {
+	imageFlags(fs, values)
+	fs.Var(&templateFlag{&values.customFormat}, "template", "")
+	fs.StringVar(&values.outputFormat, "output", "", "")
+	fs.StringVar(&values.explain, "explain", "", "")
+	fs.BoolVar(&values.byPackage, "by-package", false, "")
+}
+
+func diffFlags(fs *flag.FlagSet, values *inputValues) {
+	sourceFlags(fs, values)
+	fs.BoolVar(&values.callPaths, "callpaths", false, "")
+}
+
+// parseInputValues parses args, which starts with the executable name followed by an
+// optional command. When the command is omitted, the extract command is used.
+// Flags and files can be provided in any order.
+func parseInputValues(args []string) (values inputValues, err error) {
 	if len(args) < 2 {
 		err = errors.New(invalidSyntaxMessage)
 		return
 	}
 
-	fileName = args[len(args)-1]
-	for _, arg := range args[1:] {
-		if arg == "--dumpfile" || arg == "-d" {
-			inputIsDumpFile = true
-			continue
-		}
+	args = args[1:]
+	switch args[0] {
+	case "--help", "-help", "-h", "help":
+		err = flag.ErrHelp
+		return
+	}
 
-		if strings.HasPrefix(arg, "--template=") {
-			customFormat = strings.TrimPrefix(arg, "--template=")
+	values.command = extractCommand
+	if _, exists := commands[args[0]]; exists {
+		values.command = args[0]
+		args = args[1:]
+	}
 
-			if strings.HasPrefix(customFormat, "\"") {
-				customFormat = strings.TrimPrefix(customFormat, "\"")
-			}
+	cmd := commands[values.command]
+	fs := flag.NewFlagSet(values.command, flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	cmd.flags(fs, &values)
 
-			if strings.HasSuffix(customFormat, "\"") {
-				customFormat = strings.TrimSuffix(customFormat, "\"")
-			}
+	values.files, err = parseFlags(fs, args)
+	if err != nil {
+		return
+	}
 
-			continue
-		}
+	if len(values.files) != cmd.files && !(cmd.multipleFiles && len(values.files) > cmd.files) {
+		err = errors.New(invalidSyntaxMessage)
+		return
+	}
+	if len(values.files) > 0 {
+		values.fileName = values.files[0]
+	}
+
+	if values.jobs < 0 {
+		err = fmt.Errorf("invalid number of jobs: %d", values.jobs)
+		return
+	}
+
+	if values.outputFormat != "" && !isValidOutputFormat(values.outputFormat) {
+		err = fmt.Errorf("invalid output format: %s", values.outputFormat)
+		return
+	}
+
+	values.sourceType = exeSource
+	if values.dumpFile {
+		values.sourceType = dumpSource
+	} else if values.native {
+		values.sourceType = nativeSource
 	}
 
 	return
 }
 
+// parseFlags parses the flags in args, returning the positional arguments found between them.
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		rest := fs.Args()
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
 /*
 Run processes the source and writes the found syscalls into output.
-The parameter args contains the executable name, the optional flags followed by the filepath.
+The parameter args contains the executable name, an optional command,
+the flags and the file paths. The extract and profile commands accept multiple
+files and directories, which are processed concurrently.
 
 Example:
-[]string{ "gosystract", "--dumpfile", "filename"}
+[]string{ "gosystract", "extract", "--dumpfile", "filename"}
+
+Commands:
+
+extract           Extracts the syscalls of a go executable (default).
+
+diff              Compares the syscalls of two go executables, exiting with code 1 when syscalls were added.
+
+profile           Generates a seccomp profile for a go executable.
+
+graph             Writes the call graph of a go executable in the dot, graphml or json formats.
+
+check             Checks the syscalls of a go executable against an allow-list and a deny-list,
+exiting with code 1 when syscalls that are not permitted were found.
+
+version           Shows the version of gosystract.
 
 Flag options:
 
 --dumpfile, -d    Handles a dump file instead of go executable.
 
---template        Defines a go template for the results.
+--native          Disassembles the go executable without the go tools.
+
+--arch            Overrides the architecture detected from the file.
+
+--cgo             Includes the syscalls made through libc in cgo executables.
+
+--progress        Reports the progress of the disassembly parsing on stderr.
+
+--jobs            Defines the number of files and functions parsed concurrently (default: number of CPUs).
+
+--sort            Defines the order of the syscalls (discovery, id, name) (default: discovery).
+
+--entry           Defines a symbol or regular expression to walk from instead of main.main (repeatable).
+
+--runtime         Includes the syscalls made by the go runtime outside of the main call graph.
+
+--indirect        Defines how indirect calls are handled (conservative, direct) (default: conservative).
+
+--strict          Fails when syscalls may have been missed, e.g. when a syscall number cannot be resolved.
+
+--image           Handles OCI image layouts or docker save tarballs, extracting their go executables (extract and profile only).
+
+--template        Defines a go template for the results (extract only).
+
+--output          Defines the output format (text, json, yaml, seccomp) (extract only).
+
+--explain         Shows the call path that leads to the given syscall (extract only).
+
+--by-package      Groups the syscalls by the modules and packages that issue them (extract only),
+or collapses the call graph to package level (graph only).
+
+--format          Defines the format of the call graph (dot, graphml, json) (graph only).
+
+--prune           Only keeps the paths from the entry points that lead to syscalls (graph only).
+
+--allow           Defines the file listing the syscalls allowed (check only).
+
+--deny            Defines the file listing the syscalls denied, instead of systract.DefaultDenyList (check only).
+
+--callpaths       Shows the call paths of the syscalls added (diff only).
+
+--help            Shows the usage of the command.
 */
-func Run(stdOut io.Writer, stdErr io.Writer, args []string, extract func(source systract.SourceReader) ([]systract.SystemCall, error),
-	exit func(int)) {
+func Run(stdOut io.Writer, stdErr io.Writer, args []string, extract extractFunc, exit func(int)) {
+	values, err := parseInputValues(args)
+	if err == flag.ErrHelp {
+		printf(stdOut, "%s", getUsage(values.command))
+		return
+	}
+	if err != nil {
+		printUsage(stdErr, values.command, err)
+		exit(1)
+		return
+	}
 
-	inputIsDumpFile, customFormat, fileName, err := parseInputValues(args)
+	values.errOutput = stdErr
+	exitCode, err := commands[values.command].run(stdOut, values, extract)
 	if err != nil {
-		usage := fmt.Sprintf("gosystract version %s\n%s", gitcommit, usageMessage)
-		printf(stdErr, usage)
-		printf(stdErr, fmt.Sprintf("\nerror: %s\n", errors.New(invalidSyntaxMessage)))
+		printf(stdErr, "\nerror: %s\n", err)
 		exit(1)
 		return
 	}
 
-	var sourceReader systract.SourceReader
-	if inputIsDumpFile {
-		sourceReader = systract.NewDumpReader(fileName)
-	} else {
-		sourceReader = systract.NewExeReader(fileName)
+	if exitCode != 0 {
+		exit(exitCode)
 	}
+}
 
-	syscalls, err := extract(sourceReader)
+func runExtract(stdOut io.Writer, values inputValues, extract extractFunc) (int, error) {
+	results, failed, err := extractFiles(values, extract)
 	if err != nil {
-		printf(stdErr, fmt.Sprintf("\nerror: %s\n", err))
-		exit(1)
+		return 1, err
+	}
+
+	// the results of the files left are still labelled when others failed.
+	single := len(results) == 1 && !failed
+	switch {
+	case values.explain != "" && single:
+		err = writeCallPath(stdOut, results[0].result.Syscalls, values.explain)
+	case values.explain != "":
+		err = writeAllCallPaths(stdOut, results, values.explain)
+	case values.outputFormat == "json" || values.outputFormat == "yaml":
+		err = writeReport(stdOut, results, single, values)
+	case values.outputFormat == "seccomp":
+		err = writeSeccompProfile(stdOut, results)
+	case values.byPackage:
+		writeByPackage(stdOut, results, single)
+	case single:
+		err = writeResults(stdOut, results[0].result.Syscalls, values.customFormat)
+	default:
+		err = writeAllResults(stdOut, results, values.customFormat, values.sort)
+	}
+
+	return exitCodeOf(failed), err
+}
+
+// exitCodeOf returns exit code 1 when the extraction of any file failed.
+func exitCodeOf(failed bool) int {
+	if failed {
+		return 1
+	}
+
+	return 0
+}
+
+func runVersion(stdOut io.Writer, values inputValues, extract extractFunc) (int, error) {
+	printf(stdOut, "gosystract version %s\n", gitcommit)
+	return 0, nil
+}
+
+func isValidOutputFormat(format string) bool {
+	switch format {
+	case "text", "json", "yaml", "seccomp":
+		return true
+	}
+
+	return false
+}
+
+func getUsage(command string) string {
+	if cmd, exists := commands[command]; exists {
+		return cmd.usage
+	}
+
+	return usageMessage
+}
+
+func printUsage(stdErr io.Writer, command string, err error) {
+	usage := fmt.Sprintf("gosystract version %s\n%s", gitcommit, getUsage(command))
+	printf(stdErr, "%s", usage)
+	printf(stdErr, "\nerror: %s\n", err)
+}
+
+func newSourceReader(sourceType, fileName string) systract.SourceReader {
+	switch sourceType {
+	case dumpSource:
+		return systract.NewDumpReader(fileName)
+	case nativeSource:
+		return systract.NewElfReader(fileName)
+	default:
+		return systract.NewExeReader(fileName)
+	}
+}
+
+func getOptions(values inputValues) []systract.Option {
+	var opts []systract.Option
+	if values.arch != "" {
+		opts = append(opts, systract.WithArchitecture(values.arch))
+	}
+	if values.cgo {
+		opts = append(opts, systract.WithCgo())
+	}
+	if values.jobs > 0 {
+		opts = append(opts, systract.WithJobs(values.jobs))
+	}
+	if values.sort != "" {
+		opts = append(opts, systract.WithSort(values.sort))
+	}
+	if len(values.entryPoints) > 0 {
+		opts = append(opts, systract.WithEntryPoints(values.entryPoints...))
+	}
+	if values.runtime {
+		opts = append(opts, systract.WithRuntimeRoots())
+	}
+	if values.indirect != "" {
+		opts = append(opts, systract.WithIndirectCalls(values.indirect))
+	}
+	if values.strict {
+		opts = append(opts, systract.WithStrict())
+	}
+
+	return opts
+}
+
+// withProgress adds an option that reports the parsing progress of fileName
+// into values.errOutput, when progress is enabled.
+func withProgress(opts []systract.Option, values inputValues, fileName string) []systract.Option {
+	if !values.progress || values.errOutput == nil {
+		return opts
+	}
+
+	output := values.errOutput
+	return append(opts[:len(opts):len(opts)], systract.WithProgress(func(p systract.Progress) {
+		if p.Done {
+			printf(output, "%s: parsed %d functions from %d lines\n", fileName, p.Symbols, p.Lines)
+			return
+		}
+		printf(output, "%s: parsed %d functions from %d lines so far\n", fileName, p.Symbols, p.Lines)
+	}))
+}
+
+// writeError writes the error of the extraction of fileName into values.errOutput.
+func writeError(values inputValues, fileName string, err error) {
+	if values.errOutput == nil {
 		return
 	}
 
-	err = writeResults(stdOut, syscalls, customFormat)
-	if err != nil {
-		printf(stdErr, fmt.Sprintf("\nerror: %s\n", err))
-		exit(1)
+	printf(values.errOutput, "%s: error: %s\n", fileName, err)
+}
+
+// writeWarnings writes the warnings raised by the extraction of fileName into values.errOutput.
+func writeWarnings(values inputValues, fileName string, warnings []systract.Warning) {
+	if values.errOutput == nil {
+		return
+	}
+
+	for _, w := range warnings {
+		printf(values.errOutput, "%s: warning: %s\n", fileName, w)
 	}
 }
 
+// writeResults writes syscalls using customFormat, or the default template. The latter
+// reports the syscalls only made by the go runtime separately, as its baseline.
 func writeResults(output io.Writer, syscalls []systract.SystemCall, customFormat string) (err error) {
 	defer recoverError(&err)
 
+	var baseline []systract.SystemCall
 	t := template.Must(template.New("result").Parse(resultGoTemplate))
 	if customFormat != "" {
 		t = template.Must(template.New("result").Parse(customFormat))
+	} else {
+		syscalls, baseline = splitRuntimeSyscalls(syscalls)
 	}
 
 	e := t.Execute(output, syscalls)
 	if e != nil {
 		err = errors.New("invalid go template")
+		return
+	}
+
+	if len(baseline) > 0 {
+		printf(output, "\n%d runtime baseline system calls:\n", len(baseline))
+		for _, s := range baseline {
+			printf(output, "    %s (%d)\n", s.Name, s.ID)
+		}
 	}
 
 	return
 }
 
+func splitRuntimeSyscalls(syscalls []systract.SystemCall) (app, runtime []systract.SystemCall) {
+	app = make([]systract.SystemCall, 0, len(syscalls))
+	for _, s := range syscalls {
+		if s.Runtime {
+			runtime = append(runtime, s)
+			continue
+		}
+		app = append(app, s)
+	}
+
+	return app, runtime
+}
+
+func writeCallPath(output io.Writer, syscalls []systract.SystemCall, name string) error {
+	for _, syscall := range syscalls {
+		if syscall.Name != name {
+			continue
+		}
+
+		printf(output, "%s (%d) is reachable through:\n", syscall.Name, syscall.ID)
+		writePath(output, syscall.CallPath)
+		return nil
+	}
+
+	return fmt.Errorf("system call %s was not found", name)
+}
+
+func writePath(output io.Writer, path []string) {
+	for i, symbol := range path {
+		if i == 0 {
+			printf(output, "    %s\n", symbol)
+			continue
+		}
+		printf(output, "    -> %s\n", symbol)
+	}
+}
+
 func recoverError(err *error) {
 	if e := recover(); e != nil {
 		*err = errors.New("invalid go template")
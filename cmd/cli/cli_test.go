@@ -2,6 +2,9 @@ package cli
 
 import (
 	"bytes"
+	"flag"
+	"fmt"
+	"strings"
 	"testing"
 
 	"errors"
@@ -14,18 +17,89 @@ func TestParseInputValues(t *testing.T) {
 	assertThat := func(assumption string, args []string, expected string) {
 		should := should.New(t)
 
-		_, actual, _, err := parseInputValues(args)
+		values, err := parseInputValues(args)
 
 		should.NotError(err, assumption)
-		should.BeEqual(expected, actual, assumption)
+		should.BeEqual(expected, values.customFormat, assumption)
 	}
 
 	assertThat("should handle template flag", []string{"gosystract", "--template=\"test\"", ""}, "test")
+	assertThat("should handle template flag without quotes", []string{"gosystract", "--template=test", ""}, "test")
+	assertThat("should handle template flag followed by value", []string{"gosystract", "--template", "test", ""}, "test")
+	assertThat("should handle flags after file", []string{"gosystract", "filename", "--template={{.}}"}, "{{.}}")
+}
+
+func TestParseInputValues_Output(t *testing.T) {
+	assertThat := func(assumption string, args []string, expected string, expectedErr bool) {
+		should := should.New(t)
+
+		values, err := parseInputValues(args)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, values.outputFormat, assumption)
+	}
+
+	assertThat("should default to empty output", []string{"gosystract", "filename"}, "", false)
+	assertThat("should handle seccomp output", []string{"gosystract", "--output=seccomp", "filename"}, "seccomp", false)
+	assertThat("should handle text output", []string{"gosystract", "--output=text", "filename"}, "text", false)
+	assertThat("should handle json output", []string{"gosystract", "--output=json", "filename"}, "json", false)
+	assertThat("should handle yaml output", []string{"gosystract", "--output=yaml", "filename"}, "yaml", false)
+	assertThat("should error for unknown output", []string{"gosystract", "--output=xml", "filename"}, "xml", true)
+}
+
+func TestParseInputValues_Arch(t *testing.T) {
+	assertThat := func(assumption string, args []string, expected string) {
+		should := should.New(t)
+
+		values, err := parseInputValues(args)
+
+		should.NotError(err, assumption)
+		should.BeEqual(expected, values.arch, assumption)
+	}
+
+	assertThat("should default to empty architecture", []string{"gosystract", "filename"}, "")
+	assertThat("should handle arch flag", []string{"gosystract", "--arch=arm64", "filename"}, "arm64")
+}
+
+func TestParseInputValues_Indirect(t *testing.T) {
+	assertThat := func(assumption string, args []string, expected systract.IndirectCallMode) {
+		should := should.New(t)
+
+		values, err := parseInputValues(args)
+
+		should.NotError(err, assumption)
+		should.BeEqual(expected, values.indirect, assumption)
+	}
+
+	assertThat("should default to empty mode", []string{"gosystract", "filename"}, systract.IndirectCallMode(""))
+	assertThat("should handle indirect flag", []string{"gosystract", "--indirect=direct", "filename"},
+		systract.IndirectCallsDirect)
+	assertThat("should handle deprecated strict mode as direct", []string{"gosystract", "--indirect=strict", "filename"},
+		systract.IndirectCallsDirect)
+}
+
+func TestParseInputValues_Explain(t *testing.T) {
+	assertThat := func(assumption string, args []string, expected, expectedFileName string, expectedErr bool) {
+		should := should.New(t)
+
+		values, err := parseInputValues(args)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, values.explain, assumption)
+		should.BeEqual(expectedFileName, values.fileName, assumption)
+	}
+
+	assertThat("should default to empty explain", []string{"gosystract", "filename"}, "", "filename", false)
+	assertThat("should handle explain with equal sign", []string{"gosystract", "--explain=ptrace", "filename"}, "ptrace", "filename", false)
+	assertThat("should handle explain followed by value", []string{"gosystract", "--explain", "ptrace", "filename"}, "ptrace", "filename", false)
+	assertThat("should error when explain value is missing", []string{"gosystract", "--explain", "filename"}, "filename", "", true)
 }
 
 func TestRun(t *testing.T) {
 	assertThat := func(assumption string, args []string,
-		stub func() ([]systract.SystemCall, error), expected string,
+		stub func() (*systract.Result, error), expected string,
 		expectedToErr bool, expectedErr string) {
 
 		should := should.New(t)
@@ -33,7 +107,7 @@ func TestRun(t *testing.T) {
 		var stdOut, stdErr bytes.Buffer
 		var hasErrored bool
 
-		Run(&stdOut, &stdErr, args, func(source systract.SourceReader) ([]systract.SystemCall, error) {
+		Run(&stdOut, &stdErr, args, func(source systract.SourceReader, opts ...systract.Option) (*systract.Result, error) {
 			return stub()
 		}, func(code int) {
 			hasErrored = true
@@ -48,81 +122,302 @@ func TestRun(t *testing.T) {
 	}
 
 	assertThat("should show usage when no args", []string{},
-		func() ([]systract.SystemCall, error) { return nil, errors.New("invalid systax") },
+		func() (*systract.Result, error) { return nil, errors.New("invalid systax") },
 		"",
 		true,
 		`gosystract version TESTVERSION
 Usage:
-gosystrac [flags] filePath
+gosystract [command] [flags] filePath...
+
+Commands:
+	extract	  Extracts the syscalls of a go executable (default).
+	diff	  Compares the syscalls of two go executables.
+	profile	  Generates a seccomp profile for a go executable.
+	graph	  Writes the call graph of a go executable (dot, graphml, json).
+	check	  Checks the syscalls of a go executable against an allow-list and a deny-list.
+	version	  Shows the version of gosystract.
 
-Flags:
-	--dumpfile, -d    Handles a dump file instead of a go executable.
-	--template	  Defines a go template for the results.
+Use "gosystract [command] --help" for more information about a command.
 
 error: invalid syntax
 `)
 
 	assertThat("should show syscalls found", []string{"gosystract", "filename"},
-		func() ([]systract.SystemCall, error) {
-			return []systract.SystemCall{{ID: 1, Name: "abc"}, {ID: 2, Name: "def"}}, nil
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{{ID: 1, Name: "abc"}, {ID: 2, Name: "def"}}}, nil
 		},
 		"2 system calls found:\n    abc (1)\n    def (2)\n", false, "")
 
 	assertThat("should support custom go template for results",
 		[]string{"gosystract", "--template=\"{{- range . }}\"{{.Name}}\",{{- end}}\"", "filename"},
-		func() ([]systract.SystemCall, error) {
-			return []systract.SystemCall{{ID: 1, Name: "abc"}, {ID: 2, Name: "def"}}, nil
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{{ID: 1, Name: "abc"}, {ID: 2, Name: "def"}}}, nil
 		},
 		"\"abc\",\"def\",", false, "")
 
+	assertThat("should support custom go template without quotes",
+		[]string{"gosystract", "--template={{- range . }}\"{{.Name}}\",{{- end}}", "filename"},
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{{ID: 1, Name: "abc"}, {ID: 2, Name: "def"}}}, nil
+		},
+		"\"abc\",\"def\",", false, "")
+
+	assertThat("should write runtime baseline separately",
+		[]string{"gosystract", "--runtime", "filename"},
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{{ID: 1, Name: "write"}, {ID: 131, Name: "sigaltstack", Runtime: true},
+				{ID: 56, Name: "clone", Runtime: true}}}, nil
+		},
+		"1 system calls found:\n    write (1)\n\n2 runtime baseline system calls:\n    sigaltstack (131)\n    clone (56)\n",
+		false, "")
+
+	assertThat("should write runtime syscalls with custom go template",
+		[]string{"gosystract", "--runtime", "--template={{- range . }}{{.Name}}:{{.Runtime}},{{- end}}", "filename"},
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{{ID: 1, Name: "write"}, {ID: 56, Name: "clone", Runtime: true}}}, nil
+		},
+		"write:false,clone:true,", false, "")
+
 	assertThat("should show message when no syscalls are found",
 		[]string{"gosystract", "filename"},
-		func() ([]systract.SystemCall, error) {
-			return []systract.SystemCall{}, nil
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{}}, nil
 		},
 		"no systems calls were found\n", false, "")
 
 	assertThat("should be able to handle exec files",
 		[]string{"gosystract", "filename"},
-		func() ([]systract.SystemCall, error) {
-			return []systract.SystemCall{}, nil
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{}}, nil
 		},
 		"no systems calls were found\n", false, "")
 
 	assertThat("should not write results if extract failed",
 		[]string{"gosystract", "filename"},
-		func() ([]systract.SystemCall, error) {
+		func() (*systract.Result, error) {
 			return nil, errors.New("could not extract syscalls")
 		},
 		"",
 		true, "\nerror: could not extract syscalls\n")
 
+	assertThat("should write seccomp profile",
+		[]string{"gosystract", "--output=seccomp", "--arch=amd64", "filename"},
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{{ID: 2, Name: "def"}, {ID: 1, Name: "abc"}},
+				Metadata: systract.Metadata{Architecture: "amd64"}}, nil
+		},
+		seccompProfileOf(t, []string{"amd64"}, "abc", "def"), false, "")
+
+	assertThat("should write json report",
+		[]string{"gosystract", "--output=json", "--dumpfile", "filename"},
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{
+				{ID: 1, Name: "abc", CallPath: []string{"main.main"}},
+				{ID: 2, Name: "def", CallPath: []string{"os.init", "syscall.Socket"}},
+			}, Metadata: systract.Metadata{Architecture: "amd64", EntryPoints: []string{"main.main", "os.init"}}}, nil
+		},
+		`{
+  "metadata": {
+    "path": "filename",
+    "architecture": "amd64",
+    "entryPoints": [
+      "main.main",
+      "os.init"
+    ],
+    "toolVersion": "TESTVERSION"
+  },
+  "syscalls": [
+    {
+      "id": 1,
+      "name": "abc",
+      "callPath": [
+        "main.main"
+      ]
+    },
+    {
+      "id": 2,
+      "name": "def",
+      "callPath": [
+        "os.init",
+        "syscall.Socket"
+      ]
+    }
+  ]
+}
+`, false, "")
+
+	assertThat("should write yaml report",
+		[]string{"gosystract", "--output=yaml", "--arch=arm64", "--dumpfile", "filename"},
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{{ID: 1, Name: "abc", CallPath: []string{"main.main"}}},
+				Metadata: systract.Metadata{Architecture: "arm64", EntryPoints: []string{"main.main"}}}, nil
+		},
+		`metadata:
+  path: filename
+  architecture: arm64
+  entryPoints:
+  - main.main
+  toolVersion: TESTVERSION
+syscalls:
+- id: 1
+  name: abc
+  callPath:
+  - main.main
+`, false, "")
+
+	assertThat("should write provided entry points in reports",
+		[]string{"gosystract", "--output=yaml", "--entry=main.run", "--dumpfile", "filename"},
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{{ID: 1, Name: "abc", CallPath: []string{"main.run"}}},
+				Metadata: systract.Metadata{Architecture: "amd64", EntryPoints: []string{"main.run"}}}, nil
+		},
+		`metadata:
+  path: filename
+  architecture: amd64
+  entryPoints:
+  - main.run
+  toolVersion: TESTVERSION
+syscalls:
+- id: 1
+  name: abc
+  callPath:
+  - main.run
+`, false, "")
+
+	assertThat("should write empty syscalls list in reports",
+		[]string{"gosystract", "--output=json", "--dumpfile", "filename"},
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{},
+				Metadata: systract.Metadata{Architecture: "amd64", EntryPoints: []string{"main.main"}}}, nil
+		},
+		`{
+  "metadata": {
+    "path": "filename",
+    "architecture": "amd64",
+    "entryPoints": [
+      "main.main"
+    ],
+    "toolVersion": "TESTVERSION"
+  },
+  "syscalls": []
+}
+`, false, "")
+
+	assertThat("should write warnings to stderr",
+		[]string{"gosystract", "filename"},
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{},
+				Warnings: []systract.Warning{{Kind: systract.WarningUnresolvedCall, Message: "call target cannot be resolved",
+					Symbol: "main.run", Location: "main.go:10"}}}, nil
+		},
+		"no systems calls were found\n", false,
+		"filename: warning: call target cannot be resolved in main.run at main.go:10\n")
+
+	assertThat("should write warnings in reports",
+		[]string{"gosystract", "--output=yaml", "filename"},
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{},
+				Warnings: []systract.Warning{{Kind: systract.WarningEntryPoints, Message: "none of the default entry points were found"}},
+				Metadata: systract.Metadata{Architecture: "amd64", EntryPoints: []string{"main.main"}}}, nil
+		},
+		`metadata:
+  path: filename
+  architecture: amd64
+  entryPoints:
+  - main.main
+  toolVersion: TESTVERSION
+syscalls: []
+warnings:
+- kind: entry-points
+  message: none of the default entry points were found
+`, false, "filename: warning: none of the default entry points were found\n")
+
+	assertThat("should explain syscall call path",
+		[]string{"gosystract", "--explain", "def", "filename"},
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{
+				{ID: 1, Name: "abc", CallPath: []string{"main.main"}},
+				{ID: 2, Name: "def", CallPath: []string{"main.main", "net.Dial", "syscall.Socket"}},
+			}}, nil
+		},
+		"def (2) is reachable through:\n    main.main\n    -> net.Dial\n    -> syscall.Socket\n", false, "")
+
+	assertThat("should group syscalls by package",
+		[]string{"gosystract", "--by-package", "--dumpfile", "filename"},
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{
+				{ID: 1, Name: "abc", Packages: []string{"main", "syscall"}},
+				{ID: 2, Name: "def", Packages: []string{"example.com/lib"}},
+			}}, nil
+		},
+		`unknown module:
+    example.com/lib
+        def (2)
+    main
+        abc (1)
+std:
+    syscall
+        abc (1)
+`, false, "")
+
+	assertThat("should error when explained syscall is not found",
+		[]string{"gosystract", "--explain", "ptrace", "filename"},
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{{ID: 1, Name: "abc", CallPath: []string{"main.main"}}}}, nil
+		},
+		"", true, "\nerror: system call ptrace was not found\n")
+
 	assertThat("should error for invalid go template syntax",
 		[]string{"gosystract", "--template=\"{{$%£}\"", "filename"},
-		func() ([]systract.SystemCall, error) {
-			return []systract.SystemCall{{ID: 1, Name: "abc"}, {ID: 2, Name: "def"}}, nil
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{{ID: 1, Name: "abc"}, {ID: 2, Name: "def"}}}, nil
 		},
 		"",
 		true, "\nerror: invalid go template\n")
 
 	assertThat("should error for invalid go template syntax",
 		[]string{"gosystract", "--template=\"{{.Something}}\"", "filename"},
-		func() ([]systract.SystemCall, error) {
-			return []systract.SystemCall{{ID: 1, Name: "abc"}, {ID: 2, Name: "def"}}, nil
+		func() (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{{ID: 1, Name: "abc"}, {ID: 2, Name: "def"}}}, nil
 		},
 		"",
 		true, "\nerror: invalid go template\n")
 }
 
+func TestRun_Options(t *testing.T) {
+	assertThat := func(assumption string, args []string, expectedOpts int) {
+		should := should.New(t)
+		var stdOut, stdErr bytes.Buffer
+
+		Run(&stdOut, &stdErr, args, func(source systract.SourceReader, opts ...systract.Option) (*systract.Result, error) {
+			should.BeEqual(expectedOpts, len(opts), assumption)
+			return &systract.Result{Syscalls: []systract.SystemCall{}}, nil
+		}, func(code int) {})
+	}
+
+	assertThat("should not set options by default", []string{"gosystract", "filename"}, 0)
+	assertThat("should override architecture", []string{"gosystract", "--arch=arm64", "filename"}, 1)
+	assertThat("should enable cgo", []string{"gosystract", "--cgo", "filename"}, 1)
+	assertThat("should combine options", []string{"gosystract", "--cgo", "--arch=arm64", "filename"}, 2)
+	assertThat("should report progress", []string{"gosystract", "--progress", "filename"}, 1)
+	assertThat("should set jobs", []string{"gosystract", "--jobs=4", "filename"}, 1)
+	assertThat("should use default jobs", []string{"gosystract", "--jobs=0", "filename"}, 0)
+	assertThat("should set sort order", []string{"gosystract", "--sort=name", "filename"}, 1)
+	assertThat("should set entry points", []string{"gosystract", "--entry=main.run", "--entry", "main.serve", "filename"}, 1)
+	assertThat("should include runtime syscalls", []string{"gosystract", "--runtime", "filename"}, 1)
+	assertThat("should set indirect call mode", []string{"gosystract", "--indirect=direct", "filename"}, 1)
+	assertThat("should fail on warnings", []string{"gosystract", "--strict", "filename"}, 1)
+}
+
 func TestRun_SourceReaders(t *testing.T) {
 	assertThat := func(assumption string, args []string, expected interface{}) {
 		should := should.New(t)
 		var stdOut, stdErr bytes.Buffer
 		var hasErrored bool
 
-		Run(&stdOut, &stdErr, args, func(source systract.SourceReader) ([]systract.SystemCall, error) {
+		Run(&stdOut, &stdErr, args, func(source systract.SourceReader, opts ...systract.Option) (*systract.Result, error) {
 			should.HaveSameType(expected, source, "should be able to handle dump files")
-			return []systract.SystemCall{}, nil
+			return &systract.Result{Syscalls: []systract.SystemCall{}}, nil
 		}, func(code int) {
 			hasErrored = true
 		})
@@ -136,4 +431,119 @@ func TestRun_SourceReaders(t *testing.T) {
 	assertThat("should be able to handle dump files",
 		[]string{"gosystract", "--dumpfile", "filename"},
 		&systract.DumpReader{})
+	assertThat("should be able to disassemble exec files natively",
+		[]string{"gosystract", "--native", "filename"},
+		&systract.ElfReader{})
+}
+
+func TestParseInputValues_Commands(t *testing.T) {
+	assertThat := func(assumption string, args []string, expectedCommand string, expectedFiles []string, expectedErr error) {
+		should := should.New(t)
+
+		values, err := parseInputValues(args)
+
+		should.BeEqual(expectedErr, err, assumption)
+		should.BeEqual(expectedCommand, values.command, assumption)
+		should.BeEqual(expectedFiles, values.files, assumption)
+	}
+
+	assertThat("should default to extract command", []string{"gosystract", "filename"},
+		extractCommand, []string{"filename"}, nil)
+	assertThat("should handle extract command", []string{"gosystract", "extract", "-d", "filename"},
+		extractCommand, []string{"filename"}, nil)
+	assertThat("should handle diff command", []string{"gosystract", "diff", "old", "--callpaths", "new"},
+		diffCommand, []string{"old", "new"}, nil)
+	assertThat("should handle profile command", []string{"gosystract", "profile", "--arch", "arm64", "filename"},
+		profileCommand, []string{"filename"}, nil)
+	assertThat("should handle version command", []string{"gosystract", "version"},
+		versionCommand, []string{}, nil)
+	assertThat("should handle files after flag terminator", []string{"gosystract", "extract", "--", "--native"},
+		extractCommand, []string{"--native"}, nil)
+	assertThat("should handle top-level help", []string{"gosystract", "--help"},
+		"", []string(nil), flag.ErrHelp)
+	assertThat("should handle command help", []string{"gosystract", "diff", "--help"},
+		diffCommand, []string(nil), flag.ErrHelp)
+	assertThat("should error for unknown flags", []string{"gosystract", "--unknown", "filename"},
+		extractCommand, []string(nil), errors.New("flag provided but not defined: -unknown"))
+	assertThat("should error for flags of other commands", []string{"gosystract", "profile", "--explain=read", "filename"},
+		profileCommand, []string(nil), errors.New("flag provided but not defined: -explain"))
+	assertThat("should error when files are missing", []string{"gosystract", "diff", "old"},
+		diffCommand, []string{"old"}, errors.New(invalidSyntaxMessage))
+	assertThat("should handle multiple files", []string{"gosystract", "extract", "a", "-d", "b"},
+		extractCommand, []string{"a", "b"}, nil)
+	assertThat("should error when too many files are provided", []string{"gosystract", "version", "a"},
+		versionCommand, []string{"a"}, errors.New(invalidSyntaxMessage))
+	assertThat("should error for negative jobs", []string{"gosystract", "--jobs=-1", "a"},
+		extractCommand, []string{"a"}, errors.New("invalid number of jobs: -1"))
+	assertThat("should error for unknown sort orders", []string{"gosystract", "--sort=size", "a"},
+		extractCommand, []string(nil), errors.New(`invalid value "size" for flag -sort: invalid sort order: size`))
+	assertThat("should error for unknown indirect call modes", []string{"gosystract", "--indirect=loose", "a"},
+		extractCommand, []string(nil), errors.New(`invalid value "loose" for flag -indirect: invalid indirect call mode: loose`))
+}
+
+func TestRun_Commands(t *testing.T) {
+	assertThat := func(assumption string, args []string, expected string, expectedExitCode int, expectedErr string) {
+		should := should.New(t)
+		gitcommit = "TESTVERSION"
+		var stdOut, stdErr bytes.Buffer
+		exitCode := 0
+
+		Run(&stdOut, &stdErr, args, func(source systract.SourceReader, opts ...systract.Option) (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{{ID: 64, Name: "write"}},
+				Metadata: systract.Metadata{Architecture: "arm64"}}, nil
+		}, func(code int) {
+			exitCode = code
+		})
+
+		should.BeEqual(expectedExitCode, exitCode, assumption)
+		should.BeEqual(expected, stdOut.String(), assumption)
+		should.BeEqual(expectedErr, stdErr.String(), assumption)
+	}
+
+	assertThat("should show version", []string{"gosystract", "version"}, "gosystract version TESTVERSION\n", 0, "")
+	assertThat("should show usage for top-level help", []string{"gosystract", "--help"}, usageMessage, 0, "")
+	assertThat("should show usage for command help", []string{"gosystract", "extract", "-h"}, extractUsageMessage, 0, "")
+	assertThat("should write seccomp profile", []string{"gosystract", "profile", "--arch=arm64", "filename"},
+		seccompProfileOf(t, []string{"arm64"}, "write"), 0, "")
+	assertThat("should show command usage for unknown flags", []string{"gosystract", "profile", "--output=json", "filename"},
+		"", 1, "gosystract version TESTVERSION\n"+profileUsageMessage+"\nerror: flag provided but not defined: -output\n")
+}
+
+func TestWriteSeccompProfile(t *testing.T) {
+	assertThat := func(assumption string, arch string, expected ...string) {
+		should := should.New(t)
+		var output bytes.Buffer
+
+		err := writeSeccompProfile(&output, []fileResult{{fileName: "filename", result: &systract.Result{
+			Syscalls: []systract.SystemCall{{ID: 1, Name: "write"}},
+			Metadata: systract.Metadata{Architecture: arch}}}})
+
+		should.NotError(err, assumption)
+		for _, name := range expected {
+			should.BeTrue(strings.Contains(output.String(), fmt.Sprintf("%q", name)), assumption+": "+name)
+		}
+	}
+
+	assertThat("should allow execve", "amd64", "execve", "write")
+	assertThat("should allow the runtime baseline without --runtime", "amd64",
+		"arch_prctl", "rt_sigaction", "futex", "mmap")
+}
+
+// seccompProfileOf returns the profile expected for syscall names on archs.
+func seccompProfileOf(t *testing.T, archs []string, names ...string) string {
+	syscalls := make([]systract.SystemCall, 0, len(names))
+	for _, name := range names {
+		syscalls = append(syscalls, systract.SystemCall{Name: name})
+	}
+
+	var output bytes.Buffer
+	profile, err := systract.NewSeccompProfile(syscalls, archs...)
+	if err == nil {
+		err = profile.Write(&output)
+	}
+	if err != nil {
+		t.Fatalf("could not setup test properly, got error: %s", err)
+	}
+
+	return output.String()
 }
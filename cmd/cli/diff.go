@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"io"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+// runDiff compares the syscalls of the two files in values,
+// returning exit code 1 when syscalls were added.
+func runDiff(stdOut io.Writer, values inputValues, extract extractFunc) (int, error) {
+	opts := getOptions(values)
+	oldResult, err := extract(newSourceReader(values.sourceType, values.files[0]),
+		withProgress(opts, values, values.files[0])...)
+	if err != nil {
+		return 1, err
+	}
+	writeWarnings(values, values.files[0], oldResult.Warnings)
+
+	newResult, err := extract(newSourceReader(values.sourceType, values.files[1]),
+		withProgress(opts, values, values.files[1])...)
+	if err != nil {
+		return 1, err
+	}
+	writeWarnings(values, values.files[1], newResult.Warnings)
+
+	diff := systract.Diff(oldResult.Syscalls, newResult.Syscalls)
+	writeDiff(stdOut, diff, values.callPaths)
+
+	if diff.HasAdditions() {
+		return 1, nil
+	}
+
+	return 0, nil
+}
+
+func writeDiff(output io.Writer, diff systract.SyscallDiff, callPaths bool) {
+	if len(diff.Added) == 0 && len(diff.Removed) == 0 {
+		printf(output, "no differences were found\n")
+		return
+	}
+
+	for _, syscall := range diff.Added {
+		printf(output, "+ %s (%d)\n", syscall.Name, syscall.ID)
+		if callPaths {
+			writePath(output, syscall.CallPath)
+		}
+	}
+
+	for _, syscall := range diff.Removed {
+		printf(output, "- %s (%d)\n", syscall.Name, syscall.ID)
+	}
+
+	printf(output, "\n%d system calls added, %d removed\n", len(diff.Added), len(diff.Removed))
+}
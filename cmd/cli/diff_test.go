@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+func TestRun_Diff(t *testing.T) {
+	assertThat := func(assumption string, args []string, results [][]systract.SystemCall, extractErr error,
+		expected string, expectedExitCode int, expectedErr string) {
+
+		should := should.New(t)
+		var stdOut, stdErr bytes.Buffer
+		exitCode := 0
+		calls := 0
+
+		Run(&stdOut, &stdErr, args, func(source systract.SourceReader, opts ...systract.Option) (*systract.Result, error) {
+			if extractErr != nil {
+				return nil, extractErr
+			}
+			calls++
+			return &systract.Result{Syscalls: results[calls-1]}, nil
+		}, func(code int) {
+			exitCode = code
+		})
+
+		should.BeEqual(expectedExitCode, exitCode, assumption)
+		should.BeEqual(expected, stdOut.String(), assumption)
+		should.BeEqual(expectedErr, stdErr.String(), assumption)
+	}
+
+	write := systract.SystemCall{ID: 1, Name: "write", CallPath: []string{"main.main"}}
+	read := systract.SystemCall{ID: 0, Name: "read", CallPath: []string{"main.main", "os.Read"}}
+	ptrace := systract.SystemCall{ID: 101, Name: "ptrace", CallPath: []string{"main.main", "syscall.PtraceAttach"}}
+
+	assertThat("should report no differences",
+		[]string{"gosystract", "diff", "old", "new"},
+		[][]systract.SystemCall{{write}, {write}}, nil,
+		"no differences were found\n", 0, "")
+
+	assertThat("should not fail when syscalls are only removed",
+		[]string{"gosystract", "diff", "old", "new"},
+		[][]systract.SystemCall{{write, read}, {write}}, nil,
+		"- read (0)\n\n0 system calls added, 1 removed\n", 0, "")
+
+	assertThat("should fail when syscalls are added",
+		[]string{"gosystract", "diff", "--dumpfile", "old", "new"},
+		[][]systract.SystemCall{{read}, {write, ptrace}}, nil,
+		"+ write (1)\n+ ptrace (101)\n- read (0)\n\n2 system calls added, 1 removed\n", 1, "")
+
+	assertThat("should show call paths of added syscalls",
+		[]string{"gosystract", "diff", "--callpaths", "old", "new"},
+		[][]systract.SystemCall{{write}, {write, ptrace}}, nil,
+		"+ ptrace (101)\n    main.main\n    -> syscall.PtraceAttach\n\n1 system calls added, 0 removed\n", 1, "")
+
+	assertThat("should error when extraction fails",
+		[]string{"gosystract", "diff", "old", "new"},
+		nil, errors.New("could not extract syscalls"),
+		"", 1, "\nerror: could not extract syscalls\n")
+
+	assertThat("should show usage when files are missing",
+		[]string{"gosystract", "diff", "old"},
+		nil, nil,
+		"", 1, "gosystract version "+gitcommit+"\n"+diffUsageMessage+"\nerror: invalid syntax\n")
+}
@@ -0,0 +1,221 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+var elfMagic = []byte("\x7fELF")
+
+// fileResult holds the result of the extraction of a single file.
+type fileResult struct {
+	fileName string
+	result   *systract.Result
+	err      error
+	// entrypoint defines whether the file is the entrypoint of the image it was found in.
+	entrypoint bool
+}
+
+// extractFiles extracts the syscalls of all files in values, writing the warnings
+// raised by each extraction into values.errOutput.
+// When values are images, the go executables within them are extracted instead.
+// The files that could not be extracted are reported into values.errOutput and left out
+// of the results, with failed set. An error is only returned when no file was extracted.
+func extractFiles(values inputValues, extract extractFunc) (results []fileResult, failed bool, err error) {
+	extractAll := extractSources
+	if values.image {
+		extractAll = scanImages
+	}
+
+	all, err := extractAll(values, extract)
+	if err != nil {
+		return nil, false, err
+	}
+
+	results = make([]fileResult, 0, len(all))
+	for _, r := range all {
+		if r.err != nil {
+			if len(all) == 1 {
+				return nil, true, r.err
+			}
+			failed = true
+			writeError(values, r.fileName, r.err)
+			continue
+		}
+
+		writeWarnings(values, r.fileName, r.result.Warnings)
+		results = append(results, r)
+	}
+
+	if len(results) == 0 {
+		return nil, true, fmt.Errorf("none of the %d files could be extracted", len(all))
+	}
+
+	return results, failed, nil
+}
+
+// extractSources extracts the syscalls of all files in values concurrently, up to values.jobs
+// files at a time, returning the results in the same order the files were provided.
+// The error of each file is kept in its result.
+func extractSources(values inputValues, extract extractFunc) ([]fileResult, error) {
+	fileNames, err := getFileNames(values.files, values.sourceType, values.errOutput)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := getOptions(values)
+	results := make([]fileResult, len(fileNames))
+
+	jobs := values.jobs
+	if jobs < 1 {
+		jobs = runtime.NumCPU()
+	}
+	if jobs > len(fileNames) {
+		jobs = len(fileNames)
+	}
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	wg.Add(jobs)
+	for j := 0; j < jobs; j++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				fileName := fileNames[i]
+				result, err := extract(newSourceReader(values.sourceType, fileName), withProgress(opts, values, fileName)...)
+				results[i] = fileResult{fileName: fileName, result: result, err: err}
+			}
+		}()
+	}
+	for i := range fileNames {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return results, nil
+}
+
+// getFileNames expands the directories in paths into the files they contain.
+// Dump files are handled regardless of their format, whilst only go executables
+// are handled for executables. Other ELF files are skipped, with a notice written into notices.
+func getFileNames(paths []string, sourceType string, notices io.Writer) ([]string, error) {
+	fileNames := make([]string, 0, len(paths))
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil || !info.IsDir() {
+			fileNames = append(fileNames, path)
+			continue
+		}
+
+		err = filepath.Walk(path, func(filePath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			if sourceType == dumpSource {
+				fileNames = append(fileNames, filePath)
+				return nil
+			}
+			if !isElfFile(filePath) {
+				return nil
+			}
+
+			if err := systract.CheckGoBinary(filePath); err != nil {
+				if notices != nil {
+					printf(notices, "skipping %s: %s\n", filePath, err)
+				}
+				return nil
+			}
+			fileNames = append(fileNames, filePath)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(fileNames) == 0 {
+		return nil, fmt.Errorf("no files found in %v", paths)
+	}
+
+	return fileNames, nil
+}
+
+func isElfFile(filePath string) bool {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	magic := make([]byte, len(elfMagic))
+	if _, err := io.ReadFull(f, magic); err != nil {
+		return false
+	}
+
+	return bytes.Equal(magic, elfMagic)
+}
+
+// unionOf returns the union of the syscalls of all results, sorted by order.
+func unionOf(results []fileResult, order systract.SortOrder) []systract.SystemCall {
+	syscalls := make([][]systract.SystemCall, 0, len(results))
+	for _, r := range results {
+		syscalls = append(syscalls, r.result.Syscalls)
+	}
+
+	union := systract.Union(syscalls...)
+	_ = systract.Sort(union, order)
+	return union
+}
+
+func writeAllResults(output io.Writer, results []fileResult, customFormat string, order systract.SortOrder) error {
+	for _, r := range results {
+		if r.entrypoint {
+			printf(output, "%s (entrypoint):\n", r.fileName)
+		} else {
+			printf(output, "%s:\n", r.fileName)
+		}
+		if err := writeResults(output, r.result.Syscalls, customFormat); err != nil {
+			return err
+		}
+		printf(output, "\n")
+	}
+
+	printf(output, "all files:\n")
+	return writeResults(output, unionOf(results, order), customFormat)
+}
+
+func writeAllCallPaths(output io.Writer, results []fileResult, name string) error {
+	found := false
+	for _, r := range results {
+		for _, syscall := range r.result.Syscalls {
+			if syscall.Name != name {
+				continue
+			}
+
+			if found {
+				printf(output, "\n")
+			}
+			found = true
+
+			printf(output, "%s: %s (%d) is reachable through:\n", r.fileName, syscall.Name, syscall.ID)
+			writePath(output, syscall.CallPath)
+		}
+	}
+
+	if !found {
+		return fmt.Errorf("system call %s was not found", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,255 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pjbgf/go-test/should"
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+func TestGetFileNames(t *testing.T) {
+	assertThat := func(assumption string, paths []string, sourceType string, expected []string, expectedErr bool,
+		expectedNotices string) {
+		should := should.New(t)
+		var notices bytes.Buffer
+
+		actual, err := getFileNames(paths, sourceType, &notices)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+		should.BeEqual(expectedNotices, notices.String(), assumption)
+	}
+
+	assertThat("should keep file paths", []string{"a", "b"}, exeSource, []string{"a", "b"}, false, "")
+	assertThat("should only find executables in directories", []string{"../../test"}, exeSource,
+		[]string{"../../test/simple-app"}, false, "")
+	assertThat("should find all files in directories for dump files", []string{"../../test"}, dumpSource,
+		[]string{
+			"../../test/no-syscalls.dump",
+			"../../test/simple-app",
+			"../../test/simple-app.go",
+			"../../test/single-syscall.dump",
+			"../../test/systrac.dump",
+		}, false, "")
+	emptyDir, _ := ioutil.TempDir("", "gosystract")
+	defer os.RemoveAll(emptyDir)
+	assertThat("should error when directories have no executables", []string{emptyDir}, exeSource,
+		[]string(nil), true, "")
+
+	mixedDir, _ := ioutil.TempDir("", "gosystract")
+	defer os.RemoveAll(mixedDir)
+	copyFile(t, "../../test/simple-app", filepath.Join(mixedDir, "app"))
+	copyFile(t, "/bin/ls", filepath.Join(mixedDir, "ls"))
+	assertThat("should skip executables not built by go", []string{mixedDir}, exeSource,
+		[]string{filepath.Join(mixedDir, "app")}, false,
+		"skipping "+filepath.Join(mixedDir, "ls")+": no go sections found: not a go binary\n")
+}
+
+// copyFile copies src into dst, failing the test when it cannot be copied.
+func copyFile(t *testing.T, src, dst string) {
+	data, err := ioutil.ReadFile(src)
+	if err != nil {
+		t.Fatalf("could not setup test properly, got error: %s", err)
+	}
+	if err := ioutil.WriteFile(dst, data, 0700); err != nil {
+		t.Fatalf("could not setup test properly, got error: %s", err)
+	}
+}
+
+func TestUnionOf(t *testing.T) {
+	assertThat := func(assumption string, order systract.SortOrder, expected []systract.SystemCall) {
+		should := should.New(t)
+		results := []fileResult{
+			{result: &systract.Result{Syscalls: []systract.SystemCall{{ID: 231, Name: "exit_group"}, {ID: 1, Name: "write"}}}},
+			{result: &systract.Result{Syscalls: []systract.SystemCall{{ID: 0, Name: "read"}, {ID: 1, Name: "write"}}}},
+		}
+
+		actual := unionOf(results, order)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should keep discovery order", systract.SortByDiscovery,
+		[]systract.SystemCall{{ID: 231, Name: "exit_group"}, {ID: 1, Name: "write"}, {ID: 0, Name: "read"}})
+	assertThat("should sort union by id", systract.SortByID,
+		[]systract.SystemCall{{ID: 0, Name: "read"}, {ID: 1, Name: "write"}, {ID: 231, Name: "exit_group"}})
+	assertThat("should sort union by name", systract.SortByName,
+		[]systract.SystemCall{{ID: 231, Name: "exit_group"}, {ID: 0, Name: "read"}, {ID: 1, Name: "write"}})
+}
+
+// dumpWarnings returns the warnings written into stderr for the extraction of dump files,
+// which hold neither the go version nor the data of the executables.
+func dumpWarnings(fileNames ...string) string {
+	warnings := ""
+	for _, fileName := range fileNames {
+		warnings += fileName + ": warning: could not detect the go version, syscall wrappers of all go releases are used\n" +
+			fileName + ": warning: functions whose address is stored in data cannot be read from the source, " +
+			"their indirect calls are not followed\n"
+	}
+	return warnings
+}
+
+func TestRun_MultipleFiles(t *testing.T) {
+	assertThat := func(assumption string, args []string, expected string, expectedErr string) {
+		should := should.New(t)
+		var stdOut, stdErr bytes.Buffer
+
+		Run(&stdOut, &stdErr, args, systract.ExtractResult, func(code int) {})
+
+		should.BeEqual(expected, stdOut.String(), assumption)
+		should.BeEqual(expectedErr, stdErr.String(), assumption)
+	}
+
+	assertThat("should write results per file and their union",
+		[]string{"gosystract", "-d", "../../test/single-syscall.dump", "../../test/no-syscalls.dump"},
+		`../../test/single-syscall.dump:
+1 system calls found:
+    exit_group (231)
+
+../../test/no-syscalls.dump:
+no systems calls were found
+
+all files:
+1 system calls found:
+    exit_group (231)
+`, dumpWarnings("../../test/single-syscall.dump", "../../test/no-syscalls.dump"))
+
+	assertThat("should explain syscall call path per file",
+		[]string{"gosystract", "--explain=exit_group", "-d", "../../test/single-syscall.dump", "../../test/no-syscalls.dump"},
+		"../../test/single-syscall.dump: exit_group (231) is reachable through:\n    main.main\n",
+		dumpWarnings("../../test/single-syscall.dump", "../../test/no-syscalls.dump"))
+
+	assertThat("should write seccomp profile for all files",
+		[]string{"gosystract", "profile", "-d", "../../test/no-syscalls.dump", "../../test/single-syscall.dump"},
+		seccompProfileOf(t, []string{"amd64"}, "exit_group"), dumpWarnings("../../test/no-syscalls.dump", "../../test/single-syscall.dump"))
+
+	assertThat("should identify which file failed and keep the results of the others",
+		[]string{"gosystract", "-d", "../../test/single-syscall.dump", "file-that-dont-exist"},
+		`../../test/single-syscall.dump:
+1 system calls found:
+    exit_group (231)
+
+all files:
+1 system calls found:
+    exit_group (231)
+`, dumpWarnings("../../test/single-syscall.dump")+
+			"file-that-dont-exist: error: file does not exist or permission denied\n")
+	assertThat("should error when no file could be extracted",
+		[]string{"gosystract", "-d", "file-that-dont-exist", "other-file-that-dont-exist"},
+		"", "file-that-dont-exist: error: file does not exist or permission denied\n"+
+			"other-file-that-dont-exist: error: file does not exist or permission denied\n"+
+			"\nerror: none of the 2 files could be extracted\n")
+}
+
+func TestRun_FailedFiles(t *testing.T) {
+	assertThat := func(assumption string, args []string, expectedExit int) {
+		should := should.New(t)
+		var stdOut, stdErr bytes.Buffer
+		exitCode := 0
+
+		Run(&stdOut, &stdErr, args, func(source systract.SourceReader, opts ...systract.Option) (*systract.Result, error) {
+			if reflect.DeepEqual(source, systract.NewExeReader("broken")) {
+				return nil, errors.New("could not extract syscalls")
+			}
+			return &systract.Result{Syscalls: []systract.SystemCall{{ID: 1, Name: "write"}},
+				Metadata: systract.Metadata{Architecture: "amd64"}}, nil
+		}, func(code int) { exitCode = code })
+
+		should.BeEqual(expectedExit, exitCode, assumption)
+	}
+
+	assertThat("should not fail when all files are extracted", []string{"gosystract", "a", "b"}, 0)
+	assertThat("should fail extract after writing the others", []string{"gosystract", "a", "broken"}, 1)
+	assertThat("should fail profile after writing the others", []string{"gosystract", "profile", "a", "broken"}, 1)
+	assertThat("should fail check after writing the others", []string{"gosystract", "check", "a", "broken"}, 1)
+}
+
+func TestExtractSources_Jobs(t *testing.T) {
+	assertThat := func(assumption string, jobs int, files int, expected int) {
+		should := should.New(t)
+		var mu sync.Mutex
+		running, maxRunning := 0, 0
+		values := inputValues{jobs: jobs, sourceType: dumpSource}
+		for i := 0; i < files; i++ {
+			values.files = append(values.files, fmt.Sprintf("file%d", i))
+		}
+
+		results, err := extractSources(values, func(source systract.SourceReader, opts ...systract.Option) (*systract.Result, error) {
+			mu.Lock()
+			running++
+			if running > maxRunning {
+				maxRunning = running
+			}
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+			return &systract.Result{}, nil
+		})
+
+		should.NotError(err, assumption)
+		should.BeEqual(files, len(results), assumption)
+		should.BeEqual(expected, maxRunning, assumption)
+	}
+
+	assertThat("should extract one file at a time", 1, 4, 1)
+	assertThat("should extract up to jobs files at a time", 2, 6, 2)
+	assertThat("should not start more workers than files", 8, 3, 3)
+}
+
+func TestRun_ReportMetadata(t *testing.T) {
+	assertThat := func(assumption string, args []string, expected string, expectedErr string) {
+		should := should.New(t)
+		gitcommit = "TESTVERSION"
+		var stdOut, stdErr bytes.Buffer
+
+		Run(&stdOut, &stdErr, args, systract.ExtractResult, func(code int) {})
+
+		should.BeEqual(expected, stdOut.String(), assumption)
+		should.BeEqual(expectedErr, stdErr.String(), assumption)
+	}
+
+	assertThat("should report the entry points walked by the extraction and its warnings",
+		[]string{"gosystract", "--output=yaml", "--entry=main.main", "-d", "../../test/single-syscall.dump"},
+		`metadata:
+  path: ../../test/single-syscall.dump
+  architecture: amd64
+  entryPoints:
+  - main.main
+  - github.com/jsipprell/keyctl.init
+  - log.init
+  - main.init
+  toolVersion: TESTVERSION
+syscalls:
+- id: 231
+  name: exit_group
+  callPath:
+  - main.main
+  packages:
+  - main
+warnings:
+- kind: source
+  message: could not detect the go version, syscall wrappers of all go releases are
+    used
+- kind: source
+  message: functions whose address is stored in data cannot be read from the source,
+    their indirect calls are not followed
+`, dumpWarnings("../../test/single-syscall.dump"))
+
+	assertThat("should not report entry points that match no symbols",
+		[]string{"gosystract", "--output=yaml", "--entry=main.doesnotexist", "-d", "../../test/single-syscall.dump"},
+		"", "\nerror: entry point main.doesnotexist matched no symbols\n")
+}
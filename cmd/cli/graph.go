@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+func graphFlags(fs *flag.FlagSet, values *inputValues) {
+	sourceFlags(fs, values)
+	fs.Var(&graphFormatFlag{&values.graphFormat}, "format", "")
+	fs.BoolVar(&values.prune, "prune", false, "")
+	fs.BoolVar(&values.byPackage, "by-package", false, "")
+}
+
+// graphFormatFlag parses the --format flag, ensuring it holds one of the formats supported.
+type graphFormatFlag struct {
+	format *systract.GraphFormat
+}
+
+func (f *graphFormatFlag) String() string {
+	if f.format == nil {
+		return ""
+	}
+	return string(*f.format)
+}
+
+func (f *graphFormatFlag) Set(value string) error {
+	for _, format := range systract.GraphFormats {
+		if value == string(format) {
+			*f.format = format
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid graph format: %s", value)
+}
+
+// runGraph writes the call graph of the file in values. With --prune, only the paths
+// from the roots the syscalls were extracted from are kept.
+func runGraph(stdOut io.Writer, values inputValues, extract extractFunc) (int, error) {
+	result, err := extract(newSourceReader(values.sourceType, values.fileName),
+		withProgress(getOptions(values), values, values.fileName)...)
+	if err != nil {
+		return 1, err
+	}
+	if result == nil || result.Graph == nil {
+		return 1, fmt.Errorf("%s: no call graph was extracted", values.fileName)
+	}
+	writeWarnings(values, values.fileName, result.Warnings)
+
+	graph := result.Graph
+	if values.prune {
+		graph = graph.Prune(graph.Roots()...)
+	}
+	if values.byPackage {
+		graph = graph.ByPackage()
+	}
+
+	format := values.graphFormat
+	if format == "" {
+		format = systract.GraphDOT
+	}
+
+	return 0, graph.Write(stdOut, format)
+}
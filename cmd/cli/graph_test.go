@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+func TestRun_Graph(t *testing.T) {
+	assertThat := func(assumption string, args []string, expectedPrefix string, expectedExitCode int) {
+		should := should.New(t)
+		var stdOut, stdErr bytes.Buffer
+		exitCode := 0
+
+		Run(&stdOut, &stdErr, args, systract.ExtractResult, func(code int) {
+			exitCode = code
+		})
+
+		should.BeEqual(expectedExitCode, exitCode, assumption)
+		should.BeTrue(strings.HasPrefix(stdOut.String(), expectedPrefix), assumption)
+	}
+
+	assertThat("should write dot graphs by default",
+		[]string{"gosystract", "graph", "-d", "../../test/single-syscall.dump"}, "digraph callgraph {\n", 0)
+	assertThat("should write graphml graphs",
+		[]string{"gosystract", "graph", "--format=graphml", "-d", "../../test/single-syscall.dump"}, "<?xml", 0)
+	assertThat("should prune graphs",
+		[]string{"gosystract", "graph", "--prune", "--format=json", "-d", "../../test/single-syscall.dump"},
+		"{\n  \"symbols\": [\n    {\n      \"name\": \"main.main\"", 0)
+	assertThat("should collapse graphs by package",
+		[]string{"gosystract", "graph", "--prune", "--by-package", "-d", "../../test/single-syscall.dump"},
+		"digraph callgraph {\n\t\"main\";\n", 0)
+	assertThat("should error for invalid formats",
+		[]string{"gosystract", "graph", "--format=svg", "-d", "../../test/single-syscall.dump"}, "", 1)
+	assertThat("should error for missing files",
+		[]string{"gosystract", "graph", "-d", "missing.dump"}, "", 1)
+}
+
+func TestGraphFormatFlag(t *testing.T) {
+	should := should.New(t)
+	var format systract.GraphFormat
+	flag := &graphFormatFlag{&format}
+
+	should.NotError(flag.Set("graphml"), "should accept supported formats")
+	should.BeEqual(systract.GraphML, format, "should set format")
+	should.Error(flag.Set("svg"), "should reject unsupported formats")
+}
+
+func TestRun_GraphExtract(t *testing.T) {
+	should := should.New(t)
+	var stdOut, stdErr bytes.Buffer
+	var extracted []string
+
+	Run(&stdOut, &stdErr, []string{"gosystract", "graph", "--prune", "-d", "../../test/single-syscall.dump"},
+		func(source systract.SourceReader, opts ...systract.Option) (*systract.Result, error) {
+			result, err := systract.ExtractResult(source, opts...)
+			if err == nil {
+				extracted = append(extracted, result.Graph.Roots()...)
+			}
+			return result, err
+		}, func(code int) {})
+
+	should.BeEqual([]string{"main.main", "main.init.0", "main.init.1", "github.com/jsipprell/keyctl.init", "log.init", "main.init"},
+		extracted, "should extract the graph through the extract function provided")
+	should.BeTrue(strings.Contains(stdOut.String(), "\t\"main.main\" -> \"syscall:231\";\n"),
+		"should prune the graph from its roots")
+
+	stdOut.Reset()
+	stdErr.Reset()
+	Run(&stdOut, &stdErr, []string{"gosystract", "graph", "-d", "filename"},
+		func(source systract.SourceReader, opts ...systract.Option) (*systract.Result, error) {
+			return nil, errors.New("could not extract syscalls")
+		}, func(code int) {})
+
+	should.BeEqual("\nerror: could not extract syscalls\n", stdErr.String(), "should error when extraction fails")
+
+	stdOut.Reset()
+	stdErr.Reset()
+	exitCode := 0
+	Run(&stdOut, &stdErr, []string{"gosystract", "graph", "-d", "filename"},
+		func(source systract.SourceReader, opts ...systract.Option) (*systract.Result, error) {
+			return &systract.Result{Syscalls: []systract.SystemCall{{ID: 1, Name: "write"}}}, nil
+		}, func(code int) { exitCode = code })
+
+	should.BeEqual(1, exitCode, "should fail when no graph is extracted")
+	should.BeEqual("\nerror: filename: no call graph was extracted\n", stdErr.String(),
+		"should error when no graph is extracted")
+}
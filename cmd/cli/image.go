@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+// scanImage extracts the syscalls of the go executables within a container image with extract.
+var scanImage = func(imagePath string, extract extractFunc, opts ...systract.Option) ([]systract.ImageBinary, error) {
+	return systract.NewImageReader(imagePath).ScanWith(systract.ExtractFunc(extract), opts...)
+}
+
+// scanImages returns the results of all go executables within the images in values,
+// identified by the image path followed by their location within the image.
+func scanImages(values inputValues, extract extractFunc) ([]fileResult, error) {
+	results := make([]fileResult, 0)
+	for _, imagePath := range values.files {
+		binaries, err := scanImage(imagePath, extract, withProgress(getOptions(values), values, imagePath)...)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", imagePath, err)
+		}
+
+		for _, b := range binaries {
+			results = append(results, fileResult{
+				fileName:   imagePath + ":" + b.Path,
+				result:     b.Result,
+				err:        b.Err,
+				entrypoint: b.Entrypoint,
+			})
+		}
+	}
+
+	if len(results) == 0 {
+		return nil, fmt.Errorf("no go executables found in %v", values.files)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+func TestRun_Image(t *testing.T) {
+	assertThat := func(assumption string, args []string, binaries []systract.ImageBinary, scanErr error,
+		expected string, expectedErr string) {
+		should := should.New(t)
+		var stdOut, stdErr bytes.Buffer
+		var scanned []string
+
+		scanImage = func(imagePath string, extract extractFunc, opts ...systract.Option) ([]systract.ImageBinary, error) {
+			scanned = append(scanned, imagePath)
+			return binaries, scanErr
+		}
+		defer func() {
+			scanImage = func(imagePath string, extract extractFunc, opts ...systract.Option) ([]systract.ImageBinary, error) {
+				return systract.NewImageReader(imagePath).ScanWith(systract.ExtractFunc(extract), opts...)
+			}
+		}()
+
+		Run(&stdOut, &stdErr, args, func(source systract.SourceReader, opts ...systract.Option) (*systract.Result, error) {
+			t.Errorf("should not extract files directly when handling images")
+			return nil, nil
+		}, func(code int) {})
+
+		should.BeEqual(expected, stdOut.String(), assumption)
+		should.BeEqual(expectedErr, stdErr.String(), assumption)
+	}
+
+	server := systract.ImageBinary{Path: "/app/server", Entrypoint: true, Result: &systract.Result{
+		Syscalls: []systract.SystemCall{{ID: 1, Name: "write"}},
+		Metadata: systract.Metadata{Architecture: "amd64"}}}
+	helper := systract.ImageBinary{Path: "/bin/helper", Result: &systract.Result{
+		Syscalls: []systract.SystemCall{{ID: 63, Name: "read"}, {ID: 64, Name: "write"}},
+		Metadata: systract.Metadata{Architecture: "arm64"}}}
+
+	assertThat("should write results of image executables",
+		[]string{"gosystract", "--image", "image.tar"},
+		[]systract.ImageBinary{server, helper}, nil,
+		`image.tar:/app/server (entrypoint):
+1 system calls found:
+    write (1)
+
+image.tar:/bin/helper:
+2 system calls found:
+    read (63)
+    write (64)
+
+all files:
+2 system calls found:
+    write (1)
+    read (63)
+`, "")
+
+	assertThat("should write seccomp profile for image executables",
+		[]string{"gosystract", "profile", "--image", "image.tar"},
+		[]systract.ImageBinary{server, helper}, nil,
+		seccompProfileOf(t, []string{"amd64", "arm64"}, "read", "write"), "")
+
+	withBuildInfo := systract.ImageBinary{Path: "/app/server", Entrypoint: true, Result: &systract.Result{
+		Syscalls: []systract.SystemCall{{ID: 1, Name: "write"}},
+		Metadata: systract.Metadata{Architecture: "amd64", GoVersion: "go1.17.3", EntryPoints: []string{"main.main"}, BuildInfo: &systract.BuildInfo{
+			GoVersion: "go1.17.3", Path: "example.com/server", MainModule: "example.com/server",
+			Dependencies: []systract.Dependency{{Path: "example.com/lib", Version: "v1.0.0"}}}}}}
+	assertThat("should include build info in reports",
+		[]string{"gosystract", "--image", "--output=json", "image.tar"},
+		[]systract.ImageBinary{withBuildInfo}, nil,
+		`{
+  "metadata": {
+    "path": "image.tar:/app/server",
+    "architecture": "amd64",
+    "goVersion": "go1.17.3",
+    "buildInfo": {
+      "goVersion": "go1.17.3",
+      "path": "example.com/server",
+      "mainModule": "example.com/server",
+      "dependencies": [
+        {
+          "path": "example.com/lib",
+          "version": "v1.0.0"
+        }
+      ]
+    },
+    "entrypoint": true,
+    "entryPoints": [
+      "main.main"
+    ],
+    "toolVersion": "TESTVERSION"
+  },
+  "syscalls": [
+    {
+      "id": 1,
+      "name": "write"
+    }
+  ]
+}
+`, "")
+
+	withBuildInfo.Result.Syscalls = []systract.SystemCall{{ID: 165, Name: "mount", Packages: []string{"example.com/lib/mount"}}}
+	assertThat("should group syscalls by module",
+		[]string{"gosystract", "--image", "--by-package", "image.tar"},
+		[]systract.ImageBinary{withBuildInfo}, nil,
+		`example.com/lib v1.0.0:
+    example.com/lib/mount
+        mount (165)
+`, "")
+
+	assertThat("should error when image has no go executables",
+		[]string{"gosystract", "--image", "image.tar"},
+		[]systract.ImageBinary{}, nil,
+		"", "\nerror: no go executables found in [image.tar]\n")
+
+	broken := systract.ImageBinary{Path: "/bin/broken", Err: errors.New("could not extract syscalls from /bin/broken")}
+	assertThat("should report executables that could not be extracted and keep the others",
+		[]string{"gosystract", "--image", "image.tar"},
+		[]systract.ImageBinary{server, broken}, nil,
+		`image.tar:/app/server (entrypoint):
+1 system calls found:
+    write (1)
+
+all files:
+1 system calls found:
+    write (1)
+`, "image.tar:/bin/broken: error: could not extract syscalls from /bin/broken\n")
+
+	assertThat("should error when image cannot be scanned",
+		[]string{"gosystract", "--image", "image.tar"},
+		nil, errors.New("unsupported image format"),
+		"", "\nerror: image.tar: unsupported image format\n")
+}
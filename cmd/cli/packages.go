@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"io"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+// writeByPackage writes the syscalls of each result grouped by the
+// modules and packages that directly issue them, labelled by file unless single.
+func writeByPackage(output io.Writer, results []fileResult, single bool) {
+	for i, r := range results {
+		modules := systract.GroupByModule(r.result.Syscalls, r.result.Metadata.BuildInfo)
+		if !single {
+			if i > 0 {
+				printf(output, "\n")
+			}
+			printf(output, "%s:\n", r.fileName)
+		}
+		writeModules(output, modules)
+	}
+}
+
+func writeModules(output io.Writer, modules []systract.ModuleSyscalls) {
+	if len(modules) == 0 {
+		printf(output, "no packages were found\n")
+		return
+	}
+
+	for _, m := range modules {
+		switch {
+		case m.Module == "":
+			printf(output, "unknown module:\n")
+		case m.Version != "":
+			printf(output, "%s %s:\n", m.Module, m.Version)
+		default:
+			printf(output, "%s:\n", m.Module)
+		}
+
+		for _, p := range m.Packages {
+			printf(output, "    %s\n", p.Package)
+			for _, s := range p.Syscalls {
+				printf(output, "        %s (%d)\n", s.Name, s.ID)
+			}
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package cli
+
+import (
+	"io"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+)
+
+func runProfile(stdOut io.Writer, values inputValues, extract extractFunc) (int, error) {
+	results, failed, err := extractFiles(values, extract)
+	if err != nil {
+		return 1, err
+	}
+
+	return exitCodeOf(failed), writeSeccompProfile(stdOut, results)
+}
+
+// writeSeccompProfile writes a seccomp profile allowing the syscalls of all results,
+// for the architectures they were extracted for.
+func writeSeccompProfile(output io.Writer, results []fileResult) error {
+	archs := make([]string, 0, len(results))
+	for _, r := range results {
+		archs = append(archs, r.result.Metadata.Architecture)
+	}
+
+	profile, err := systract.NewSeccompProfile(unionOf(results, systract.SortByDiscovery), archs...)
+	if err != nil {
+		return err
+	}
+
+	return profile.Write(output)
+}
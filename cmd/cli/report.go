@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/pjbgf/gosystract/cmd/systract"
+	"gopkg.in/yaml.v2"
+)
+
+// report is the schema used by the json and yaml output formats.
+type report struct {
+	Metadata reportMetadata        `json:"metadata" yaml:"metadata"`
+	Syscalls []systract.SystemCall `json:"syscalls" yaml:"syscalls"`
+	// Warnings describe conditions in which syscalls may have been missed.
+	Warnings []systract.Warning `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	// Modules groups the syscalls by the modules and packages that issue them,
+	// and is only set with --by-package.
+	Modules []systract.ModuleSyscalls `json:"modules,omitempty" yaml:"modules,omitempty"`
+}
+
+type reportMetadata struct {
+	Path         string              `json:"path" yaml:"path"`
+	Architecture string              `json:"architecture" yaml:"architecture"`
+	GoVersion    string              `json:"goVersion,omitempty" yaml:"goVersion,omitempty"`
+	BuildInfo    *systract.BuildInfo `json:"buildInfo,omitempty" yaml:"buildInfo,omitempty"`
+	Entrypoint   bool                `json:"entrypoint,omitempty" yaml:"entrypoint,omitempty"`
+	EntryPoints  []string            `json:"entryPoints" yaml:"entryPoints"`
+	ToolVersion  string              `json:"toolVersion" yaml:"toolVersion"`
+}
+
+// aggregatedReport is the schema used by the json and yaml output formats
+// when multiple files are processed.
+type aggregatedReport struct {
+	Files    []*report             `json:"files" yaml:"files"`
+	Syscalls []systract.SystemCall `json:"syscalls" yaml:"syscalls"`
+}
+
+// newReport returns the report of a single file, based on the metadata of its extraction.
+func newReport(r fileResult, values inputValues) *report {
+	metadata := r.result.Metadata
+
+	var modules []systract.ModuleSyscalls
+	if values.byPackage {
+		modules = systract.GroupByModule(r.result.Syscalls, metadata.BuildInfo)
+	}
+
+	return &report{
+		Metadata: reportMetadata{
+			Path:         r.fileName,
+			Architecture: metadata.Architecture,
+			GoVersion:    metadata.GoVersion,
+			BuildInfo:    metadata.BuildInfo,
+			Entrypoint:   r.entrypoint,
+			EntryPoints:  metadata.EntryPoints,
+			ToolVersion:  gitcommit,
+		},
+		Syscalls: r.result.Syscalls,
+		Warnings: r.result.Warnings,
+		Modules:  modules,
+	}
+}
+
+// writeReport writes the report of a single file, or an aggregated report
+// with the union of the syscalls when multiple files were processed.
+func writeReport(output io.Writer, results []fileResult, single bool, values inputValues) error {
+	reports := make([]*report, 0, len(results))
+	for _, r := range results {
+		reports = append(reports, newReport(r, values))
+	}
+
+	var r interface{} = reports[0]
+	if !single {
+		r = &aggregatedReport{Files: reports, Syscalls: unionOf(results, values.sort)}
+	}
+
+	if values.outputFormat == "yaml" {
+		return yaml.NewEncoder(output).Encode(r)
+	}
+
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
@@ -8,5 +8,5 @@ import (
 )
 
 func main() {
-	cli.Run(os.Stdout, os.Stderr, os.Args, systract.Extract, os.Exit)
+	cli.Run(os.Stdout, os.Stderr, os.Args, systract.ExtractResult, os.Exit)
 }
@@ -72,11 +72,17 @@ func TestMain_ErrorCodes(t *testing.T) {
 	assertThat("should exit with code 1 if no args provided", "gosystract", "exit status 1",
 		`gosystract version [ not set ]
 Usage:
-gosystrac [flags] filePath
+gosystract [command] [flags] filePath...
 
-Flags:
-	--dumpfile, -d    Handles a dump file instead of a go executable.
-	--template	  Defines a go template for the results.
+Commands:
+	extract	  Extracts the syscalls of a go executable (default).
+	diff	  Compares the syscalls of two go executables.
+	profile	  Generates a seccomp profile for a go executable.
+	graph	  Writes the call graph of a go executable (dot, graphml, json).
+	check	  Checks the syscalls of a go executable against an allow-list and a deny-list.
+	version	  Shows the version of gosystract.
+
+Use "gosystract [command] --help" for more information about a command.
 
 error: invalid syntax
 `)
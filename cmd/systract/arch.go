@@ -0,0 +1,64 @@
+package systract
+
+import (
+	"debug/elf"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+// ErrUnsupportedArch is returned when the architecture of an application is not supported.
+var ErrUnsupportedArch = errors.New("architecture not supported")
+
+// ArchitectureReader defines the interface for sources that can
+// detect the architecture of the application they read.
+type ArchitectureReader interface {
+	Architecture() (string, error)
+}
+
+// DetectArchitecture returns the architecture of the application read by source.
+// It falls back to DefaultArchitecture when source cannot detect it.
+func DetectArchitecture(source SourceReader) (string, error) {
+	if r, ok := source.(ArchitectureReader); ok {
+		return r.Architecture()
+	}
+
+	return DefaultArchitecture, nil
+}
+
+func getElfArchitecture(filePath string) (string, error) {
+	f, err := elf.Open(filePath)
+	if err != nil {
+		return "", errors.Wrap(err, "could not open elf file")
+	}
+	defer f.Close()
+
+	return elfMachineToArch(f.FileHeader)
+}
+
+func elfMachineToArch(header elf.FileHeader) (string, error) {
+	switch header.Machine {
+	case elf.EM_X86_64:
+		return "amd64", nil
+	case elf.EM_386:
+		return "386", nil
+	case elf.EM_AARCH64:
+		return "arm64", nil
+	case elf.EM_ARM:
+		return "arm", nil
+	case elf.EM_PPC64:
+		if header.ByteOrder == binary.LittleEndian {
+			return "ppc64le", nil
+		}
+	case elf.EM_S390:
+		if header.Class == elf.ELFCLASS64 {
+			return "s390x", nil
+		}
+	case elf.EM_RISCV:
+		if header.Class == elf.ELFCLASS64 {
+			return "riscv64", nil
+		}
+	}
+
+	return "", errors.Wrap(ErrUnsupportedArch, header.Machine.String())
+}
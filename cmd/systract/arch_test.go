@@ -0,0 +1,60 @@
+package systract
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+	"github.com/pkg/errors"
+)
+
+func TestElfMachineToArch(t *testing.T) {
+	assertThat := func(assumption string, header elf.FileHeader, expected string, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := elfMachineToArch(header)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+		if expectedErr {
+			should.BeEqual(ErrUnsupportedArch, errors.Cause(err), assumption)
+		}
+	}
+
+	assertThat("should detect amd64", elf.FileHeader{Machine: elf.EM_X86_64}, "amd64", false)
+	assertThat("should detect 386", elf.FileHeader{Machine: elf.EM_386}, "386", false)
+	assertThat("should detect arm64", elf.FileHeader{Machine: elf.EM_AARCH64}, "arm64", false)
+	assertThat("should detect arm", elf.FileHeader{Machine: elf.EM_ARM}, "arm", false)
+	assertThat("should detect ppc64le",
+		elf.FileHeader{Machine: elf.EM_PPC64, ByteOrder: binary.LittleEndian}, "ppc64le", false)
+	assertThat("should not support ppc64 big endian",
+		elf.FileHeader{Machine: elf.EM_PPC64, ByteOrder: binary.BigEndian}, "", true)
+	assertThat("should detect s390x",
+		elf.FileHeader{Machine: elf.EM_S390, Class: elf.ELFCLASS64}, "s390x", false)
+	assertThat("should detect riscv64",
+		elf.FileHeader{Machine: elf.EM_RISCV, Class: elf.ELFCLASS64}, "riscv64", false)
+	assertThat("should error for unsupported machines", elf.FileHeader{Machine: elf.EM_MIPS}, "", true)
+}
+
+func TestDetectArchitecture(t *testing.T) {
+	assertThat := func(assumption string, source SourceReader, expected string, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := DetectArchitecture(source)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should detect architecture from executables",
+		NewExeReader("../../test/simple-app"), "amd64", false)
+	assertThat("should error when executable does not exist",
+		NewExeReader("file-that-dont-exist"), "", true)
+	assertThat("should error when file is not an executable",
+		NewExeReader("../../test/simple-app.go"), "", true)
+	assertThat("should fallback to default architecture for dump files",
+		NewDumpReader("../../test/single-syscall.dump"), DefaultArchitecture, false)
+}
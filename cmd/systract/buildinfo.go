@@ -0,0 +1,139 @@
+package systract
+
+import (
+	"debug/buildinfo"
+	"debug/elf"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNotGoBinary is returned when the file being read was not built by the go toolchain.
+var ErrNotGoBinary = errors.New("not a go binary")
+
+// goSections are the ELF sections created by the go toolchain. Go 1.13 onwards
+// include .go.buildinfo, which is also used by debug/buildinfo.
+var goSections = []string{".go.buildinfo", ".gopclntab", ".note.go.buildid"}
+
+// BuildInfo represents the information embedded by the go toolchain into executables.
+type BuildInfo struct {
+	GoVersion string `json:"goVersion" yaml:"goVersion"`
+	// Path is the package path of the main package.
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+	// MainModule is the module path containing the main package.
+	MainModule   string       `json:"mainModule,omitempty" yaml:"mainModule,omitempty"`
+	Dependencies []Dependency `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+	GOOS         string       `json:"goos,omitempty" yaml:"goos,omitempty"`
+	GOARCH       string       `json:"goarch,omitempty" yaml:"goarch,omitempty"`
+	CGOEnabled   string       `json:"cgoEnabled,omitempty" yaml:"cgoEnabled,omitempty"`
+	// Settings holds all the build settings, e.g. "-tags" or "vcs.revision".
+	// Executables built before go 1.18 do not contain build settings.
+	Settings map[string]string `json:"settings,omitempty" yaml:"settings,omitempty"`
+}
+
+// Dependency represents a module the executable was built with.
+type Dependency struct {
+	Path    string `json:"path" yaml:"path"`
+	Version string `json:"version" yaml:"version"`
+	// Replace is the path of the module replacing this dependency, if any.
+	Replace string `json:"replace,omitempty" yaml:"replace,omitempty"`
+}
+
+// BuildInfoReader defines the interface for sources that can read
+// the build information of the application they read.
+type BuildInfoReader interface {
+	BuildInfo() (*BuildInfo, error)
+}
+
+// GoVersionReader defines the interface for sources that can detect
+// the go release used to build the application they read.
+type GoVersionReader interface {
+	GoVersion() (string, error)
+}
+
+// DetectBuildInfo returns the build information of the application read by source.
+// Nil is returned when source cannot read it.
+func DetectBuildInfo(source SourceReader) (*BuildInfo, error) {
+	if r, ok := source.(BuildInfoReader); ok {
+		return r.BuildInfo()
+	}
+
+	return nil, nil
+}
+
+// DetectGoVersion returns the go release used to build the application read by source,
+// e.g. "go1.17.3". An empty version is returned when source cannot detect it.
+func DetectGoVersion(source SourceReader) (string, error) {
+	if r, ok := source.(GoVersionReader); ok {
+		return r.GoVersion()
+	}
+
+	return "", nil
+}
+
+// CheckGoBinary returns ErrNotGoBinary when filePath is not an ELF
+// executable containing any of the sections created by the go toolchain.
+// It can be used to skip other executables before they are read.
+func CheckGoBinary(filePath string) error {
+	f, err := elf.Open(filePath)
+	if err != nil {
+		return errors.Wrap(ErrNotGoBinary, "file is not an ELF executable")
+	}
+	defer f.Close()
+
+	for _, section := range goSections {
+		if f.Section(section) != nil {
+			return nil
+		}
+	}
+
+	return errors.Wrap(ErrNotGoBinary, "no go sections found")
+}
+
+func isGoBinary(filePath string) bool {
+	return CheckGoBinary(filePath) == nil
+}
+
+func getBuildInfo(filePath string) (*BuildInfo, error) {
+	if err := CheckGoBinary(filePath); err != nil {
+		return nil, err
+	}
+
+	info, err := buildinfo.ReadFile(filePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read go build information")
+	}
+
+	bi := &BuildInfo{
+		GoVersion:    info.GoVersion,
+		Path:         info.Path,
+		MainModule:   info.Main.Path,
+		Dependencies: make([]Dependency, 0, len(info.Deps)),
+		Settings:     make(map[string]string, len(info.Settings)),
+	}
+
+	for _, dep := range info.Deps {
+		d := Dependency{Path: dep.Path, Version: dep.Version}
+		if dep.Replace != nil {
+			d.Replace = dep.Replace.Path
+		}
+		bi.Dependencies = append(bi.Dependencies, d)
+	}
+
+	for _, s := range info.Settings {
+		bi.Settings[s.Key] = s.Value
+	}
+	bi.GOOS = bi.Settings["GOOS"]
+	bi.GOARCH = bi.Settings["GOARCH"]
+	bi.CGOEnabled = bi.Settings["CGO_ENABLED"]
+
+	return bi, nil
+}
+
+func getGoVersion(filePath string) (string, error) {
+	info, err := getBuildInfo(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return info.GoVersion, nil
+}
@@ -0,0 +1,98 @@
+package systract
+
+import (
+	"debug/elf"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+	"github.com/pkg/errors"
+)
+
+func TestDetectGoVersion(t *testing.T) {
+	assertThat := func(assumption string, source SourceReader, expected string, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := DetectGoVersion(source)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should detect go version from executables",
+		NewExeReader("../../test/simple-app"), "go1.13.4", false)
+	assertThat("should error when file is not an executable",
+		NewElfReader("../../test/simple-app.go"), "", true)
+	assertThat("should return empty version for dump files",
+		NewDumpReader("../../test/single-syscall.dump"), "", false)
+}
+
+func TestDetectBuildInfo(t *testing.T) {
+	assertThat := func(assumption string, source SourceReader, expected *BuildInfo, expectedErr error) {
+		should := should.New(t)
+
+		actual, err := DetectBuildInfo(source)
+
+		should.BeEqual(expectedErr, errors.Cause(err), assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should read build info from executables", NewExeReader("../../test/simple-app"),
+		&BuildInfo{
+			GoVersion:    "go1.13.4",
+			Path:         "command-line-arguments",
+			MainModule:   "github.com/pjbgf/gosystract",
+			Dependencies: []Dependency{},
+			Settings:     map[string]string{},
+		}, nil)
+	assertThat("should read build info natively", NewElfReader("../../test/simple-app"),
+		&BuildInfo{
+			GoVersion:    "go1.13.4",
+			Path:         "command-line-arguments",
+			MainModule:   "github.com/pjbgf/gosystract",
+			Dependencies: []Dependency{},
+			Settings:     map[string]string{},
+		}, nil)
+	assertThat("should error for files that are not go binaries", NewExeReader("../../test/simple-app.go"),
+		(*BuildInfo)(nil), ErrNotGoBinary)
+	assertThat("should return nil for dump files", NewDumpReader("../../test/single-syscall.dump"),
+		(*BuildInfo)(nil), nil)
+}
+
+func TestCheckGoBinary(t *testing.T) {
+	assertThat := func(assumption, filePath string, expected error) {
+		should := should.New(t)
+
+		err := CheckGoBinary(filePath)
+
+		should.BeEqual(expected, errors.Cause(err), assumption)
+	}
+
+	// a valid ELF header without any sections
+	elfHeader := make([]byte, 64)
+	copy(elfHeader, []byte{0x7f, 'E', 'L', 'F', 2, 1, 1})
+	binary.LittleEndian.PutUint16(elfHeader[16:], uint16(elf.ET_EXEC))
+	binary.LittleEndian.PutUint16(elfHeader[18:], uint16(elf.EM_X86_64))
+	binary.LittleEndian.PutUint32(elfHeader[20:], 1)
+	binary.LittleEndian.PutUint16(elfHeader[52:], 64)
+	nonGoElf, _ := ioutil.TempFile("", "gosystract-test")
+	nonGoElf.Write(elfHeader)
+	nonGoElf.Close()
+	defer os.Remove(nonGoElf.Name())
+
+	assertThat("should accept go binaries", "../../test/simple-app", nil)
+	assertThat("should reject files that are not ELF", "../../test/simple-app.go", ErrNotGoBinary)
+	assertThat("should reject ELF files without go sections", nonGoElf.Name(), ErrNotGoBinary)
+	assertThat("should reject files that do not exist", "file-that-dont-exist", ErrNotGoBinary)
+}
+
+func TestExeReader_GetReader_NotGoBinary(t *testing.T) {
+	should := should.New(t)
+
+	_, err := NewExeReader("../../test/simple-app.go").GetReader()
+
+	should.BeEqual(ErrNotGoBinary, errors.Cause(err), "should reject files that are not go binaries")
+}
@@ -0,0 +1,146 @@
+package systract
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SyscallWrapper represents a go function that dispatches system calls.
+type SyscallWrapper struct {
+	// Symbol is the fully qualified name of the function.
+	Symbol string
+	// ArgIndex is the position of the argument holding the syscall number.
+	ArgIndex int
+	// Syscall is the name of the system call made by wrappers that
+	// do not receive the syscall number as an argument.
+	Syscall string
+	// Since and Until define the go releases in which the function exists.
+	// Empty values mean the function is not bound to a go release.
+	Since string
+	Until string
+}
+
+// SyscallCatalog is a list of functions that dispatch system calls.
+type SyscallCatalog []SyscallWrapper
+
+// DefaultCatalog contains the syscall wrappers from the go standard library,
+// the go runtime and golang.org/x/sys/unix.
+var DefaultCatalog = SyscallCatalog{
+	{Symbol: "syscall.Syscall", ArgIndex: 0},
+	{Symbol: "syscall.Syscall6", ArgIndex: 0},
+	{Symbol: "syscall.RawSyscall", ArgIndex: 0},
+	{Symbol: "syscall.RawSyscall6", ArgIndex: 0},
+	{Symbol: "syscall.rawSyscallNoError", ArgIndex: 0, Since: "1.11"},
+	{Symbol: "syscall.rawVforkSyscall", ArgIndex: 0, Since: "1.13"},
+	{Symbol: "syscall.AllThreadsSyscall", ArgIndex: 0, Since: "1.16"},
+	{Symbol: "syscall.AllThreadsSyscall6", ArgIndex: 0, Since: "1.16"},
+	{Symbol: "runtime/internal/syscall.Syscall6", ArgIndex: 0, Since: "1.19", Until: "1.22"},
+	{Symbol: "internal/runtime/syscall.Syscall6", ArgIndex: 0, Since: "1.23", Until: "1.24"},
+	{Symbol: "internal/runtime/syscall/linux.Syscall6", ArgIndex: 0, Since: "1.25"},
+	{Symbol: "runtime.nanotime1", Syscall: "clock_gettime", Since: "1.14"},
+	{Symbol: "runtime.walltime1", Syscall: "clock_gettime", Until: "1.16"},
+	{Symbol: "runtime.walltime", Syscall: "clock_gettime", Since: "1.17"},
+	{Symbol: "golang.org/x/sys/unix.Syscall", ArgIndex: 0},
+	{Symbol: "golang.org/x/sys/unix.Syscall6", ArgIndex: 0},
+	{Symbol: "golang.org/x/sys/unix.RawSyscall", ArgIndex: 0},
+	{Symbol: "golang.org/x/sys/unix.RawSyscall6", ArgIndex: 0},
+	{Symbol: "golang.org/x/sys/unix.SyscallNoError", ArgIndex: 0},
+	{Symbol: "golang.org/x/sys/unix.RawSyscallNoError", ArgIndex: 0},
+}
+
+// ForGoVersion returns the wrappers that exist in the given go release,
+// e.g. "go1.17.3" or "1.17". All wrappers are returned when version is empty.
+func (c SyscallCatalog) ForGoVersion(version string) SyscallCatalog {
+	if version == "" {
+		return c
+	}
+
+	wrappers := make(SyscallCatalog, 0, len(c))
+	for _, w := range c {
+		if isInGoVersion(version, w.Since, w.Until) {
+			wrappers = append(wrappers, w)
+		}
+	}
+
+	return wrappers
+}
+
+// isInGoVersion returns whether version is within the go releases since and until,
+// which are ignored when empty. Any release is accepted when version is empty.
+func isInGoVersion(version, since, until string) bool {
+	if version == "" {
+		return true
+	}
+	if since != "" && compareGoVersions(version, since) < 0 {
+		return false
+	}
+
+	return until == "" || compareGoVersions(version, until) <= 0
+}
+
+// Lookup returns the wrapper for the given symbol.
+func (c SyscallCatalog) Lookup(symbol string) (SyscallWrapper, bool) {
+	symbol = strings.TrimSuffix(symbol, ".abi0")
+	for _, w := range c {
+		if w.Symbol == symbol {
+			return w, true
+		}
+	}
+
+	return SyscallWrapper{}, false
+}
+
+func (c SyscallCatalog) index() map[string]SyscallWrapper {
+	index := make(map[string]SyscallWrapper, len(c))
+	for _, w := range c {
+		index[w.Symbol] = w
+	}
+
+	return index
+}
+
+// compareGoVersions compares go releases by their major and minor versions,
+// returning -1, 0 or 1 when a is older, the same or newer than b.
+func compareGoVersions(a, b string) int {
+	aMajor, aMinor := parseGoVersion(a)
+	bMajor, bMinor := parseGoVersion(b)
+
+	switch {
+	case aMajor != bMajor:
+		return compareInts(aMajor, bMajor)
+	default:
+		return compareInts(aMinor, bMinor)
+	}
+}
+
+func parseGoVersion(version string) (major, minor int) {
+	parts := strings.SplitN(strings.TrimPrefix(version, "go"), ".", 3)
+	major, _ = strconv.Atoi(parts[0])
+	if len(parts) > 1 {
+		minor, _ = strconv.Atoi(leadingDigits(parts[1]))
+	}
+
+	return
+}
+
+// leadingDigits handles pre-releases such as "21rc1".
+func leadingDigits(value string) string {
+	for i, r := range value {
+		if r < '0' || r > '9' {
+			return value[:i]
+		}
+	}
+
+	return value
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
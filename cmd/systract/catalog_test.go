@@ -0,0 +1,69 @@
+package systract
+
+import (
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestSyscallCatalog_ForGoVersion(t *testing.T) {
+	assertThat := func(assumption, version, symbol string, expected bool) {
+		should := should.New(t)
+
+		_, found := DefaultCatalog.ForGoVersion(version).Lookup(symbol)
+
+		should.BeEqual(expected, found, assumption)
+	}
+
+	assertThat("should include all wrappers when version is unknown", "", "runtime/internal/syscall.Syscall6", true)
+	assertThat("should include wrappers not bound to releases", "go1.13", "syscall.Syscall", true)
+	assertThat("should exclude wrappers introduced later", "go1.13", "runtime/internal/syscall.Syscall6", false)
+	assertThat("should include wrappers from their first release", "go1.19", "runtime/internal/syscall.Syscall6", true)
+	assertThat("should include wrappers until their last release", "go1.22.5", "runtime/internal/syscall.Syscall6", true)
+	assertThat("should exclude wrappers after their last release", "go1.23", "runtime/internal/syscall.Syscall6", false)
+	assertThat("should include renamed wrappers", "go1.23", "internal/runtime/syscall.Syscall6", true)
+	assertThat("should handle pre-releases", "go1.23rc1", "internal/runtime/syscall.Syscall6", true)
+	assertThat("should exclude renamed wrappers after their last release", "go1.25",
+		"internal/runtime/syscall.Syscall6", false)
+	assertThat("should include wrappers moved into per-OS packages", "go1.25",
+		"internal/runtime/syscall/linux.Syscall6", true)
+	assertThat("should exclude wrappers moved into per-OS packages before their move", "go1.24.3",
+		"internal/runtime/syscall/linux.Syscall6", false)
+}
+
+func TestSyscallCatalog_Lookup(t *testing.T) {
+	assertThat := func(assumption, symbol string, expected SyscallWrapper, expectedFound bool) {
+		should := should.New(t)
+
+		actual, found := DefaultCatalog.Lookup(symbol)
+
+		should.BeEqual(expectedFound, found, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should find wrappers by symbol", "syscall.Syscall6",
+		SyscallWrapper{Symbol: "syscall.Syscall6", ArgIndex: 0}, true)
+	assertThat("should find ABI0 wrappers", "syscall.Syscall6.abi0",
+		SyscallWrapper{Symbol: "syscall.Syscall6", ArgIndex: 0}, true)
+	assertThat("should find wrappers with fixed syscalls", "runtime.nanotime1",
+		SyscallWrapper{Symbol: "runtime.nanotime1", Syscall: "clock_gettime", Since: "1.14"}, true)
+	assertThat("should not find generic vdso trampolines", "runtime.vdsoCall",
+		SyscallWrapper{}, false)
+	assertThat("should not find other functions", "syscall.Open",
+		SyscallWrapper{}, false)
+}
+
+func TestCompareGoVersions(t *testing.T) {
+	assertThat := func(assumption, a, b string, expected int) {
+		should := should.New(t)
+
+		actual := compareGoVersions(a, b)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should compare minor versions", "go1.13", "1.19", -1)
+	assertThat("should ignore patch versions", "go1.19.3", "1.19", 0)
+	assertThat("should compare two digits minor versions", "go1.20", "1.9", 1)
+	assertThat("should compare major versions", "go2.0", "1.21", 1)
+}
@@ -0,0 +1,69 @@
+package systract
+
+// SyscallDiff represents the system calls added and removed between two extractions.
+type SyscallDiff struct {
+	// Added contains the system calls only found in the newer extraction,
+	// alongside the call paths that reach them.
+	Added []SystemCall `json:"added" yaml:"added"`
+	// Removed contains the system calls no longer found in the newer extraction.
+	Removed []SystemCall `json:"removed" yaml:"removed"`
+}
+
+// HasAdditions returns whether new system calls were found.
+func (d SyscallDiff) HasAdditions() bool {
+	return len(d.Added) > 0
+}
+
+// Diff compares the system calls of a with the ones of b, returning the system calls
+// that were added or removed in b. System calls are matched by name, so
+// extractions for different architectures can also be compared.
+func Diff(a, b []SystemCall) SyscallDiff {
+	return SyscallDiff{
+		Added:   missingSyscalls(b, a),
+		Removed: missingSyscalls(a, b),
+	}
+}
+
+// missingSyscalls returns the system calls in source that are not in target.
+func missingSyscalls(source, target []SystemCall) []SystemCall {
+	names := make(map[string]bool, len(target))
+	for _, s := range target {
+		names[s.Name] = true
+	}
+
+	missing := make([]SystemCall, 0)
+	for _, s := range source {
+		if !names[s.Name] {
+			missing = append(missing, s)
+		}
+	}
+
+	return missing
+}
+
+// Union merges the system calls of all results, matching them by name.
+// System calls are kept in the order they are first found,
+// alongside the shortest call path that reaches them and all the packages that issue them.
+// System calls are only marked as Runtime when they are marked as such in all results.
+func Union(results ...[]SystemCall) []SystemCall {
+	union := make([]SystemCall, 0)
+	unique := make(map[string]int)
+
+	for _, syscalls := range results {
+		for _, s := range syscalls {
+			if i, exists := unique[s.Name]; exists {
+				if isShorterPath(s.CallPath, union[i].CallPath) {
+					union[i].CallPath = s.CallPath
+				}
+				union[i].Packages = mergePackages(union[i].Packages, s.Packages)
+				union[i].Runtime = union[i].Runtime && s.Runtime
+				continue
+			}
+
+			unique[s.Name] = len(union)
+			union = append(union, s)
+		}
+	}
+
+	return union
+}
@@ -0,0 +1,70 @@
+package systract
+
+import (
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestDiff(t *testing.T) {
+	assertThat := func(assumption string, a, b []SystemCall, expected SyscallDiff) {
+		should := should.New(t)
+
+		actual := Diff(a, b)
+
+		should.BeEqual(expected, actual, assumption)
+		should.BeEqual(len(expected.Added) > 0, actual.HasAdditions(), assumption)
+	}
+
+	write := SystemCall{ID: 1, Name: "write", CallPath: []string{"main.main", "syscall.write"}}
+	read := SystemCall{ID: 0, Name: "read", CallPath: []string{"main.main", "syscall.read"}}
+	ptrace := SystemCall{ID: 101, Name: "ptrace", CallPath: []string{"main.main", "syscall.PtraceAttach"}}
+
+	assertThat("should return no differences for empty sets", nil, nil,
+		SyscallDiff{Added: []SystemCall{}, Removed: []SystemCall{}})
+	assertThat("should return no differences for the same syscalls",
+		[]SystemCall{write, read}, []SystemCall{read, write},
+		SyscallDiff{Added: []SystemCall{}, Removed: []SystemCall{}})
+	assertThat("should report added syscalls with their call paths",
+		[]SystemCall{write}, []SystemCall{write, ptrace},
+		SyscallDiff{Added: []SystemCall{ptrace}, Removed: []SystemCall{}})
+	assertThat("should report removed syscalls",
+		[]SystemCall{write, read}, []SystemCall{write},
+		SyscallDiff{Added: []SystemCall{}, Removed: []SystemCall{read}})
+	assertThat("should report added and removed syscalls",
+		[]SystemCall{read}, []SystemCall{ptrace},
+		SyscallDiff{Added: []SystemCall{ptrace}, Removed: []SystemCall{read}})
+	assertThat("should match syscalls by name across architectures",
+		[]SystemCall{{ID: 1, Name: "write"}}, []SystemCall{{ID: 64, Name: "write"}},
+		SyscallDiff{Added: []SystemCall{}, Removed: []SystemCall{}})
+}
+
+func TestUnion(t *testing.T) {
+	assertThat := func(assumption string, results [][]SystemCall, expected []SystemCall) {
+		should := should.New(t)
+
+		actual := Union(results...)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	write := SystemCall{ID: 1, Name: "write", CallPath: []string{"main.main", "os.Write", "syscall.write"}}
+	shorterWrite := SystemCall{ID: 1, Name: "write", CallPath: []string{"main.main", "syscall.write"}}
+	read := SystemCall{ID: 0, Name: "read", CallPath: []string{"main.main", "syscall.read"}}
+
+	assertThat("should return empty union when no results", nil, []SystemCall{})
+	assertThat("should keep syscalls of single result", [][]SystemCall{{write, read}}, []SystemCall{write, read})
+	assertThat("should merge syscalls in discovery order",
+		[][]SystemCall{{write}, {read, write}}, []SystemCall{write, read})
+	assertThat("should keep shortest call path",
+		[][]SystemCall{{write, read}, {shorterWrite}}, []SystemCall{shorterWrite, read})
+	assertThat("should merge packages",
+		[][]SystemCall{
+			{{ID: 1, Name: "write", Packages: []string{"syscall"}}},
+			{{ID: 1, Name: "write", Packages: []string{"os", "syscall"}}},
+		},
+		[]SystemCall{{ID: 1, Name: "write", Packages: []string{"os", "syscall"}}})
+	assertThat("should not mark syscalls made by applications as runtime",
+		[][]SystemCall{{{ID: 56, Name: "clone", Runtime: true}}, {{ID: 56, Name: "clone"}}},
+		[]SystemCall{{ID: 56, Name: "clone"}})
+}
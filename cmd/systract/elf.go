@@ -0,0 +1,456 @@
+package systract
+
+import (
+	"bufio"
+	"bytes"
+	"debug/elf"
+	"debug/gosym"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	"github.com/pkg/errors"
+	"golang.org/x/arch/arm/armasm"
+	"golang.org/x/arch/arm64/arm64asm"
+	"golang.org/x/arch/ppc64/ppc64asm"
+	"golang.org/x/arch/x86/x86asm"
+)
+
+// ElfReader represents a go executables reader.
+// Differently from ExeReader, it disassembles the executable natively,
+// without depending on the go tools being installed.
+type ElfReader struct {
+	elfFile
+}
+
+// NewElfReader initialises a new ElfReader
+func NewElfReader(exeFilePath string) *ElfReader {
+	return &ElfReader{elfFile{exeFilePath}}
+}
+
+// GetReader returns a io.ReadCloser with the disassembled executable,
+// using the same format generated by go tool objdump.
+func (e *ElfReader) GetReader() (io.ReadCloser, error) {
+	filePath, err := sanitiseFileName(e.filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !fileExists(filePath) {
+		return nil, errors.New("file does not exist or permission denied")
+	}
+	if err := CheckGoBinary(filePath); err != nil {
+		return nil, err
+	}
+
+	return getNativeDumpReader(filePath)
+}
+
+// elfFile reads the details of a go executable that are independent of how it is disassembled,
+// being shared by ExeReader and ElfReader.
+type elfFile struct {
+	filePath string
+}
+
+// Architecture returns the architecture the executable was built for.
+func (e *elfFile) Architecture() (string, error) {
+	filePath, err := sanitiseFileName(e.filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return getElfArchitecture(filePath)
+}
+
+// ImportedSymbols returns the symbols the executable imports from shared libraries.
+func (e *elfFile) ImportedSymbols() ([]string, error) {
+	filePath, err := sanitiseFileName(e.filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return getElfImportedSymbols(filePath)
+}
+
+// GoVersion returns the go release the executable was built with.
+func (e *elfFile) GoVersion() (string, error) {
+	filePath, err := sanitiseFileName(e.filePath)
+	if err != nil {
+		return "", err
+	}
+
+	return getGoVersion(filePath)
+}
+
+// BuildInfo returns the information embedded by the go toolchain into the executable.
+func (e *elfFile) BuildInfo() (*BuildInfo, error) {
+	filePath, err := sanitiseFileName(e.filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return getBuildInfo(filePath)
+}
+
+// LinkerStubs returns where the stubs calling into shared libraries are, such as .plt.
+func (e *elfFile) LinkerStubs() ([]AddressRange, error) {
+	filePath, err := sanitiseFileName(e.filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return getElfLinkerStubs(filePath)
+}
+
+// AddressTakenFunctions returns the functions whose address is stored in the data of the executable.
+func (e *elfFile) AddressTakenFunctions() ([]string, error) {
+	filePath, err := sanitiseFileName(e.filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return getElfAddressTakenFunctions(filePath)
+}
+
+type elfSymbol struct {
+	name string
+	addr uint64
+	size uint64
+}
+
+type disassembler struct {
+	text     []byte
+	textAddr uint64
+	funcs    []elfSymbol
+	symbols  []elfSymbol
+	lines    *gosym.Table
+	decode   func(code []byte, pc uint64, lookup func(uint64) (string, uint64)) (string, int)
+}
+
+func getNativeDumpReader(filePath string) (io.ReadCloser, error) {
+	f, err := elf.Open(filePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open elf file")
+	}
+
+	d, err := newDisassembler(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(d.dump(writer))
+	}()
+
+	return reader, nil
+}
+
+func newDisassembler(f *elf.File) (*disassembler, error) {
+	arch, err := elfMachineToArch(f.FileHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	decode, err := getDecoder(arch)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := loadFunctions(f)
+	if err != nil {
+		return nil, err
+	}
+	d.decode = decode
+
+	return d, nil
+}
+
+// loadFunctions reads the .text section and the symbols of its functions,
+// returning a disassembler without a decoder.
+func loadFunctions(f *elf.File) (*disassembler, error) {
+	section := f.Section(".text")
+	if section == nil {
+		return nil, errors.New("could not find .text section")
+	}
+	text, err := section.Data()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read .text section")
+	}
+
+	d := &disassembler{
+		text:     text,
+		textAddr: section.Addr,
+	}
+
+	d.loadSymbols(f)
+	d.loadLineTable(f)
+
+	if len(d.funcs) == 0 {
+		return nil, errors.New("could not find function symbols in .gopclntab or .symtab")
+	}
+
+	return d, nil
+}
+
+// loadSymbols reads the .symtab symbols, used to resolve addresses into names
+// and as source of function boundaries for executables without .gopclntab.
+func (d *disassembler) loadSymbols(f *elf.File) {
+	symbols, err := f.Symbols()
+	if err != nil {
+		return
+	}
+
+	for _, s := range symbols {
+		typ := elf.ST_TYPE(s.Info)
+		if s.Section == elf.SHN_UNDEF || (typ != elf.STT_FUNC && typ != elf.STT_OBJECT) {
+			continue
+		}
+
+		sym := elfSymbol{name: s.Name, addr: s.Value, size: s.Size}
+		d.symbols = append(d.symbols, sym)
+		if typ == elf.STT_FUNC && s.Size > 0 && d.inText(s.Value) {
+			d.funcs = append(d.funcs, sym)
+		}
+	}
+
+	sortSymbols(d.symbols)
+	sortSymbols(d.funcs)
+}
+
+// loadLineTable reads the .gopclntab section, which is preferred as source of
+// function boundaries and is kept even when executables are stripped.
+func (d *disassembler) loadLineTable(f *elf.File) {
+	section := f.Section(".gopclntab")
+	if section == nil {
+		return
+	}
+	data, err := section.Data()
+	if err != nil {
+		return
+	}
+
+	textStart := d.textAddr
+	if s, found := d.lookupExact("runtime.text"); found {
+		textStart = s.addr
+	}
+
+	table, err := gosym.NewTable(nil, gosym.NewLineTable(data, textStart))
+	if err != nil {
+		return
+	}
+
+	// The names of .symtab are kept, as call targets are resolved through them and,
+	// differently from .gopclntab, they carry the ABI suffix of assembly functions.
+	names := make(map[uint64]string, len(d.funcs))
+	for _, fn := range d.funcs {
+		names[fn.addr] = fn.name
+	}
+
+	d.lines = table
+	d.funcs = make([]elfSymbol, 0, len(table.Funcs))
+	for _, fn := range table.Funcs {
+		if fn.End <= fn.Entry || !d.inText(fn.Entry) {
+			continue
+		}
+
+		name := fn.Name
+		if symName, found := names[fn.Entry]; found {
+			name = symName
+		}
+		d.funcs = append(d.funcs, elfSymbol{name: name, addr: fn.Entry, size: fn.End - fn.Entry})
+	}
+	sortSymbols(d.funcs)
+
+	if len(d.symbols) == 0 {
+		d.symbols = d.funcs
+	}
+}
+
+func (d *disassembler) inText(addr uint64) bool {
+	return addr >= d.textAddr && addr < d.textAddr+uint64(len(d.text))
+}
+
+func (d *disassembler) lookupExact(name string) (elfSymbol, bool) {
+	for _, s := range d.symbols {
+		if s.name == name {
+			return s, true
+		}
+	}
+
+	return elfSymbol{}, false
+}
+
+// lookup resolves an address into the symbol that contains it.
+func (d *disassembler) lookup(addr uint64) (string, uint64) {
+	i := sort.Search(len(d.symbols), func(i int) bool {
+		return d.symbols[i].addr > addr
+	}) - 1
+
+	if i >= 0 {
+		s := d.symbols[i]
+		if addr == s.addr || addr < s.addr+s.size {
+			return s.name, s.addr
+		}
+	}
+
+	return "", 0
+}
+
+func (d *disassembler) fileLine(pc uint64) (string, int) {
+	if d.lines == nil {
+		return "?", 0
+	}
+
+	file, line, _ := d.lines.PCToLine(pc)
+	if file == "" {
+		return "?", 0
+	}
+
+	return file, line
+}
+
+// dump writes the disassembled functions in the format used by go tool objdump.
+// Functions are disassembled concurrently, and written in the order they appear in the executable.
+func (d *disassembler) dump(output io.Writer) error {
+	jobs := runtime.GOMAXPROCS(0)
+	ordered := make(chan chan []byte, jobs*2)
+	limit := make(chan struct{}, jobs)
+	stop := make(chan struct{})
+	defer close(stop)
+
+	go func() {
+		defer close(ordered)
+		for _, fn := range d.funcs {
+			result := make(chan []byte, 1)
+			select {
+			case ordered <- result:
+			case <-stop:
+				return
+			}
+
+			limit <- struct{}{}
+			go func(fn elfSymbol) {
+				result <- d.disassemble(fn)
+				<-limit
+			}(fn)
+		}
+	}()
+
+	w := bufio.NewWriter(output)
+	for result := range ordered {
+		if _, err := w.Write(<-result); err != nil {
+			return err
+		}
+	}
+
+	return w.Flush()
+}
+
+func (d *disassembler) disassemble(fn elfSymbol) []byte {
+	var buf bytes.Buffer
+	start := fn.addr - d.textAddr
+	end := start + fn.size
+	if end > uint64(len(d.text)) {
+		end = uint64(len(d.text))
+	}
+
+	file, _ := d.fileLine(fn.addr)
+	fmt.Fprintf(&buf, "TEXT %s(SB) %s\n", fn.name, file)
+
+	code := d.text[start:end]
+	for i := 0; i < len(code); {
+		pc := fn.addr + uint64(i)
+		text, size := d.decode(code[i:], pc, d.lookup)
+		if size <= 0 || i+size > len(code) {
+			size = len(code) - i
+		}
+
+		file, line := d.fileLine(pc)
+		fmt.Fprintf(&buf, "  %s:%d\t%#x\t\t%x\t\t%s\n", filepath.Base(file), line, pc, code[i:i+size], text)
+		i += size
+	}
+	buf.WriteString("\n")
+
+	return buf.Bytes()
+}
+
+func getDecoder(arch string) (func([]byte, uint64, func(uint64) (string, uint64)) (string, int), error) {
+	switch arch {
+	case "amd64":
+		return decodeX86(64), nil
+	case "386":
+		return decodeX86(32), nil
+	case "arm64":
+		return decodeArm64, nil
+	case "arm":
+		return decodeArm, nil
+	case "ppc64le":
+		return decodePpc64le, nil
+	}
+
+	return nil, errors.Wrapf(ErrUnsupportedArch, "native disassembly of %s", arch)
+}
+
+func decodeX86(mode int) func([]byte, uint64, func(uint64) (string, uint64)) (string, int) {
+	return func(code []byte, pc uint64, lookup func(uint64) (string, uint64)) (string, int) {
+		inst, err := x86asm.Decode(code, mode)
+		if err != nil {
+			return "?", 1
+		}
+
+		return x86asm.GoSyntax(inst, pc, lookup), inst.Len
+	}
+}
+
+func decodeArm64(code []byte, pc uint64, lookup func(uint64) (string, uint64)) (string, int) {
+	inst, err := arm64asm.Decode(code)
+	if err != nil {
+		return "?", 4
+	}
+
+	return arm64asm.GoSyntax(inst, pc, lookup, textReader{code, pc}), 4
+}
+
+func decodeArm(code []byte, pc uint64, lookup func(uint64) (string, uint64)) (string, int) {
+	inst, err := armasm.Decode(code, armasm.ModeARM)
+	if err != nil {
+		return "?", 4
+	}
+
+	return armasm.GoSyntax(inst, pc, lookup, textReader{code, pc}), inst.Len
+}
+
+func decodePpc64le(code []byte, pc uint64, lookup func(uint64) (string, uint64)) (string, int) {
+	inst, err := ppc64asm.Decode(code, binary.LittleEndian)
+	if err != nil || inst.Len == 0 {
+		return "?", 4
+	}
+
+	return ppc64asm.GoSyntax(inst, pc, lookup), inst.Len
+}
+
+// textReader exposes the code being decoded through its absolute addresses,
+// so decoders can resolve pc-relative constants.
+type textReader struct {
+	code []byte
+	pc   uint64
+}
+
+func (r textReader) ReadAt(data []byte, off int64) (int, error) {
+	if off < 0 || uint64(off) < r.pc || uint64(off)-r.pc >= uint64(len(r.code)) {
+		return 0, io.EOF
+	}
+
+	n := copy(data, r.code[uint64(off)-r.pc:])
+	return n, nil
+}
+
+func sortSymbols(symbols []elfSymbol) {
+	sort.Slice(symbols, func(i, j int) bool {
+		return symbols[i].addr < symbols[j].addr
+	})
+}
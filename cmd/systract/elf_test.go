@@ -0,0 +1,172 @@
+package systract
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestElfReader_GetReader_Integration(t *testing.T) {
+	assertThat := func(assumption, filePath string, expectedErr bool) {
+		should := should.New(t)
+		reader := NewElfReader(filePath)
+
+		r, err := reader.GetReader()
+		if r != nil {
+			r.Close()
+		}
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+	}
+
+	assertThat("should error when file not found",
+		"file-that-dont-exist",
+		true)
+	assertThat("should error when file is not an executable",
+		"../../test/simple-app.go",
+		true)
+	assertThat("should be able disassemble go executables",
+		"../../test/simple-app",
+		false)
+
+	// test the handling of current chdir being deleted
+	wdSnapshot, _ := os.Getwd()
+	tmpFolder, err := ioutil.TempDir("", "zaz-test")
+	if err != nil {
+		t.Error(err)
+		t.FailNow()
+	}
+	os.Chdir(tmpFolder)
+	os.Remove(tmpFolder)
+
+	assertThat("should error if current directly disappears",
+		"any-file", true)
+
+	// returns snapshotted working directory to ensure other tests' repeatability
+	os.Chdir(wdSnapshot)
+}
+
+func TestElfReader_Output(t *testing.T) {
+	should := should.New(t)
+	reader, err := NewElfReader("../../test/simple-app").GetReader()
+	should.NotError(err, "should not error for simple-app")
+	defer reader.Close()
+
+	scanner := bufio.NewScanner(reader)
+	lines := make([]string, 0)
+	for scanner.Scan() && len(lines) < 3 {
+		lines = append(lines, scanner.Text())
+	}
+
+	should.BeEqual([]string{
+		"TEXT internal/cpu.Initialize(SB) /usr/local/go/src/internal/cpu/cpu.go",
+		"  cpu.go:141\t0x401000\t\t64488b0c25f8ffffff\t\tMOVQ FS:0xfffffff8, CX",
+		"  cpu.go:141\t0x401009\t\t483b6110\t\tCMPQ 0x10(CX), SP",
+	}, lines, "should use the same format as go tool objdump")
+}
+
+func TestExtract_E2E_NativeDisassembly(t *testing.T) {
+	should := should.New(t)
+
+	expected, err := Extract(NewExeReader("../../test/simple-app"))
+	should.NotError(err, "should not error for objdump")
+
+	actual, err := Extract(NewElfReader("../../test/simple-app"))
+	should.NotError(err, "should not error for native disassembly")
+
+	sortSyscallsByID(expected)
+	sortSyscallsByID(actual)
+	should.BeEqual(expected, actual, "should find the same syscalls as objdump")
+}
+
+func TestExtract_E2E_NativeDisassembly_Go117(t *testing.T) {
+	should := should.New(t)
+	dir, err := ioutil.TempDir("", "gosystract-test")
+	if err != nil {
+		t.Fatalf("could not setup test properly, got error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// executables built since go 1.17 call assembly functions through their ABI0 symbols,
+	// so the current toolchain is used instead of the go 1.13 simple-app.
+	exePath := filepath.Join(dir, "simple-app")
+	if out, err := exec.Command("go", "build", "-o", exePath, "../../test/simple-app.go").CombinedOutput(); err != nil {
+		t.Fatalf("could not build test executable: %s: %s", err, out)
+	}
+
+	expected, err := Extract(NewExeReader(exePath), WithSort(SortByID))
+	should.NotError(err, "should not error for objdump")
+
+	actual, err := Extract(NewElfReader(exePath), WithSort(SortByID))
+	should.NotError(err, "should not error for native disassembly")
+
+	should.BeEqual(expected, actual, "should find the same syscalls and call paths as objdump")
+}
+
+func TestElfReader_Architecture(t *testing.T) {
+	should := should.New(t)
+
+	arch, err := NewElfReader("../../test/simple-app").Architecture()
+
+	should.NotError(err, "should detect architecture")
+	should.BeEqual("amd64", arch, "should detect amd64 executables")
+}
+
+func TestGetDecoder(t *testing.T) {
+	assertThat := func(assumption, arch string, expectedErr bool) {
+		should := should.New(t)
+
+		_, err := getDecoder(arch)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+	}
+
+	assertThat("should support amd64", "amd64", false)
+	assertThat("should support 386", "386", false)
+	assertThat("should support arm64", "arm64", false)
+	assertThat("should support arm", "arm", false)
+	assertThat("should support ppc64le", "ppc64le", false)
+	assertThat("should not support s390x", "s390x", true)
+}
+
+func TestDecoders(t *testing.T) {
+	assertThat := func(assumption, arch string, code []byte, expectedText string, expectedSize int) {
+		should := should.New(t)
+		decode, _ := getDecoder(arch)
+
+		text, size := decode(code, 0x1000, func(addr uint64) (string, uint64) {
+			return "", 0
+		})
+
+		should.BeEqual(expectedText, text, assumption)
+		should.BeEqual(expectedSize, size, assumption)
+	}
+
+	assertThat("should decode amd64 instructions", "amd64", []byte{0xb8, 0xe7, 0x00, 0x00, 0x00}, "MOVL $0xe7, AX", 5)
+	assertThat("should decode amd64 syscall", "amd64", []byte{0x0f, 0x05}, "SYSCALL", 2)
+	assertThat("should decode arm64 instructions", "arm64", []byte{0xa8, 0x0b, 0x80, 0xd2}, "MOVD $93, R8", 4)
+	assertThat("should decode arm64 svc", "arm64", []byte{0x01, 0x00, 0x00, 0xd4}, "SVC $0", 4)
+	assertThat("should skip invalid instructions", "arm64", []byte{0x00, 0x00, 0x00, 0x00}, "?", 4)
+}
+
+func TestTextReader(t *testing.T) {
+	should := should.New(t)
+	r := textReader{code: []byte{1, 2, 3, 4}, pc: 0x1000}
+	data := make([]byte, 2)
+
+	n, err := r.ReadAt(data, 0x1002)
+	should.NotError(err, "should read within code boundaries")
+	should.BeEqual(2, n, "should read requested bytes")
+	should.BeEqual([]byte{3, 4}, data, "should read from absolute address")
+
+	_, err = r.ReadAt(data, 0x2000)
+	should.BeEqual(io.EOF, err, "should return EOF outside code boundaries")
+}
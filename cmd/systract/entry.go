@@ -0,0 +1,73 @@
+package systract
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// cgoExportPrefix prefixes the wrappers generated by cgo for go functions exported
+// with //export, which are called from C in c-shared and c-archive builds.
+const cgoExportPrefix string = "_cgoexp_"
+
+// getEntryPoints returns the symbols the call graph is walked from: patterns or, when
+// none is provided, DefaultEntryPoints, followed by the cgo exports and init functions.
+// Each pattern is either a symbol name or a regular expression matching whole symbol names.
+// Patterns matching no symbols are reported as errors.
+func getEntryPoints(graph *callGraph, patterns []string) ([]string, error) {
+	ep := DefaultEntryPoints
+	if len(patterns) > 0 {
+		resolved, err := resolveEntryPoints(graph, patterns)
+		if err != nil {
+			return nil, err
+		}
+		ep = resolved
+	}
+
+	ep = append(append([]string{}, ep...), extractCgoExports(graph)...)
+	return append(ep, extractInitSymbols(graph)...), nil
+}
+
+func resolveEntryPoints(graph *callGraph, patterns []string) ([]string, error) {
+	ep := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if _, exists := graph.lookup(pattern); exists {
+			ep = append(ep, pattern)
+			continue
+		}
+
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid entry point %s", pattern)
+		}
+
+		matches := make([]string, 0)
+		for _, name := range graph.names {
+			if re.MatchString(name) {
+				matches = append(matches, name)
+			}
+		}
+		if len(matches) == 0 {
+			return nil, errors.Errorf("entry point %s matched no symbols", pattern)
+		}
+		sort.Strings(matches)
+		ep = append(ep, matches...)
+	}
+
+	return ep, nil
+}
+
+// extractCgoExports returns the cgo wrappers of exported functions, sorted by name.
+func extractCgoExports(graph *callGraph) []string {
+	exports := make([]string, 0)
+	for _, name := range graph.names {
+		if strings.HasPrefix(name, cgoExportPrefix) {
+			exports = append(exports, name)
+		}
+	}
+	sort.Strings(exports)
+
+	return exports
+}
@@ -0,0 +1,48 @@
+package systract
+
+import (
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestGetEntryPoints(t *testing.T) {
+	assertThat := func(assumption string, patterns []string, expected []string, expectedErr bool) {
+		should := should.New(t)
+		graph := graphOf(map[string]symbolDefinition{
+			"main.main":                   {subCalls: []string{"main.run"}},
+			"main.run":                    {syscallIDs: []uint16{1}},
+			"plugin/unnamed-1.Handle":     {syscallIDs: []uint16{0}},
+			"plugin/unnamed-1.HandleMore": {syscallIDs: []uint16{3}},
+			"_cgoexp_c2e4f8aec88c_Hello":  {subCalls: []string{"main.Hello"}},
+			"os.init":                     {syscallIDs: []uint16{39}},
+		})
+
+		actual, err := getEntryPoints(graph, patterns)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should use default entry points",
+		nil, []string{"main.main", "main.init.0", "main.init.1", "_cgoexp_c2e4f8aec88c_Hello", "os.init"}, false)
+	assertThat("should replace default entry points with symbol names",
+		[]string{"main.run"}, []string{"main.run", "_cgoexp_c2e4f8aec88c_Hello", "os.init"}, false)
+	assertThat("should match whole symbol names with regular expressions",
+		[]string{`plugin/unnamed-\d+\.Handle.*`},
+		[]string{"plugin/unnamed-1.Handle", "plugin/unnamed-1.HandleMore", "_cgoexp_c2e4f8aec88c_Hello", "os.init"}, false)
+	assertThat("should error for invalid regular expressions",
+		[]string{"main.(run"}, []string(nil), true)
+	assertThat("should error for patterns matching no symbols",
+		[]string{"main.run", "main.doesnotexist"}, []string(nil), true)
+}
+
+func TestExtract_EntryPoints(t *testing.T) {
+	should := should.New(t)
+
+	syscalls, err := Extract(NewDumpReader("../../test/single-syscall.dump"), WithEntryPoints("main.init"))
+
+	should.NotError(err, "should extract syscalls from custom entry points")
+	should.BeEqual([]SystemCall{}, syscalls, "should not walk default entry points when custom ones are provided")
+}
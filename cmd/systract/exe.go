@@ -1,27 +1,77 @@
 package systract
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os/exec"
-	"runtime"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
+const (
+	// maxStderrSize is how much of the stderr of objdump is kept, to be reported on failures.
+	maxStderrSize int = 4096
+	// commandWaitDelay is how long the output of a command is waited for once it is
+	// killed or exits, as the processes it started may still hold it open.
+	commandWaitDelay = time.Second
+)
+
+// ContextSourceReader defines the interface for source readers whose reading can be cancelled.
+type ContextSourceReader interface {
+	SourceReader
+	GetReaderContext(ctx context.Context) (io.ReadCloser, error)
+}
+
+// ErrObjdumpFailed is the cause of all ObjdumpError, so they can be checked with errors.Is.
+var ErrObjdumpFailed = errors.New("objdump failed")
+
+// ObjdumpError is returned when objdump fails to disassemble an executable.
+type ObjdumpError struct {
+	ExitCode int
+	// Stderr holds the beginning of what objdump wrote to stderr.
+	Stderr string
+}
+
+func (e *ObjdumpError) Error() string {
+	stderr := strings.TrimSpace(e.Stderr)
+	if stderr == "" {
+		return fmt.Sprintf("objdump exited with code %d", e.ExitCode)
+	}
+
+	return fmt.Sprintf("objdump exited with code %d: %s", e.ExitCode, stderr)
+}
+
+// Unwrap returns ErrObjdumpFailed.
+func (e *ObjdumpError) Unwrap() error {
+	return ErrObjdumpFailed
+}
+
 // ExeReader represents a go executables reader.
 // Internally it will call go tool objdump in order to get a disassembled dump of the file.
+// When the go tools are not available, it falls back to native disassembly (see ElfReader).
 type ExeReader struct {
-	filePath string
+	elfFile
 }
 
 // NewExeReader initialises a new ExeReader
 func NewExeReader(exeFilePath string) *ExeReader {
-	return &ExeReader{exeFilePath}
+	return &ExeReader{elfFile{exeFilePath}}
 }
 
 // GetReader returns a io.ReadCloser based of the filePath
 func (e *ExeReader) GetReader() (io.ReadCloser, error) {
+	return e.GetReaderContext(context.Background())
+}
+
+// GetReaderContext returns a io.ReadCloser based of the filePath. Objdump is killed
+// when ctx is done, and its failures are returned as ObjdumpError once its output is read.
+func (e *ExeReader) GetReaderContext(ctx context.Context) (io.ReadCloser, error) {
 	filePath, err := sanitiseFileName(e.filePath)
 	if err != nil {
 		return nil, err
@@ -29,26 +79,154 @@ func (e *ExeReader) GetReader() (io.ReadCloser, error) {
 	if !fileExists(filePath) {
 		return nil, errors.New("file does not exist or permission denied")
 	}
+	if err := CheckGoBinary(filePath); err != nil {
+		return nil, err
+	}
 
-	objDumpFilePath := getObjDumpFilePath()
-	return getFileDumpReader(objDumpFilePath, filePath)
+	return getFileDumpReader(ctx, getObjDumpFilePath(), filePath)
 }
 
+var (
+	objDumpFilePath     string
+	objDumpFilePathOnce sync.Once
+)
+
+// getObjDumpFilePath returns the path of the objdump of the go toolchain in PATH,
+// based on its GOTOOLDIR. Empty is returned when go is not installed.
 func getObjDumpFilePath() string {
-	return fmt.Sprintf("/usr/local/go/pkg/tool/%s_%s/objdump", runtime.GOOS, runtime.GOARCH)
+	objDumpFilePathOnce.Do(func() {
+		objDumpFilePath = lookupObjDumpFilePath()
+	})
+
+	return objDumpFilePath
 }
 
-func getFileDumpReader(objDumpFilePath, filePath string) (io.ReadCloser, error) {
-	/* #nosec filePath is pre-processed by sanitiseFileName */
-	cmd := exec.Command(objDumpFilePath, filePath)
+func lookupObjDumpFilePath() string {
+	output, err := exec.Command("go", "env", "GOTOOLDIR").Output()
+	if err != nil {
+		return ""
+	}
 
-	if !fileExists(objDumpFilePath) {
-		/* #nosec filePath is pre-processed by sanitiseFileName */
-		cmd = exec.Command("go", "tool", "objdump", filePath)
+	toolDir := strings.TrimSpace(string(output))
+	if toolDir == "" {
+		return ""
 	}
 
+	return filepath.Join(toolDir, "objdump")
+}
+
+// getFileDumpReader starts objDumpFilePath for filePath, falling back
+// to native disassembly when objdump does not exist.
+func getFileDumpReader(ctx context.Context, objDumpFilePath, filePath string) (io.ReadCloser, error) {
+	if objDumpFilePath == "" || !fileExists(objDumpFilePath) {
+		return getNativeDumpReader(filePath)
+	}
+
+	/* #nosec filePath is pre-processed by sanitiseFileName */
+	return startCommand(ctx, exec.CommandContext(ctx, objDumpFilePath, filePath))
+}
+
+// commandReader reads the output of a command, which is waited for once its output is
+// read to the end or the reader is closed, so its exit status is not lost and no zombie
+// processes are left behind.
+type commandReader struct {
+	ctx    context.Context
+	cmd    *exec.Cmd
+	output io.ReadCloser
+	stderr *limitedBuffer
+	once   sync.Once
+	err    error
+	// done is closed once the command is waited for.
+	done chan struct{}
+}
+
+func startCommand(ctx context.Context, cmd *exec.Cmd) (io.ReadCloser, error) {
 	output, err := cmd.StdoutPipe()
-	defer cmd.Start()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not start objdump")
+	}
+
+	stderr := &limitedBuffer{limit: maxStderrSize}
+	cmd.Stderr = stderr
+	cmd.WaitDelay = commandWaitDelay
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, "could not start objdump")
+	}
+
+	r := &commandReader{ctx: ctx, cmd: cmd, output: output, stderr: stderr, done: make(chan struct{})}
+	go func() {
+		select {
+		case <-ctx.Done():
+			// the command is killed by its context, but the processes it started, such as
+			// the objdump started by go tool, only stop once they cannot write their output.
+			output.Close()
+		case <-r.done:
+		}
+	}()
+
+	return r, nil
+}
+
+func (r *commandReader) Read(p []byte) (int, error) {
+	n, err := r.output.Read(p)
+	if err != nil && (err == io.EOF || r.ctx.Err() != nil) {
+		if waitErr := r.wait(); waitErr != nil {
+			return n, waitErr
+		}
+	}
+
+	return n, err
+}
+
+// Close kills the command when its output was not read to the end.
+func (r *commandReader) Close() error {
+	r.once.Do(func() {
+		r.output.Close()
+		_ = r.cmd.Process.Kill()
+		_ = r.cmd.Wait()
+		close(r.done)
+	})
+
+	return nil
+}
+
+func (r *commandReader) wait() error {
+	r.once.Do(func() {
+		defer close(r.done)
+		err := r.cmd.Wait()
+		if r.ctx.Err() != nil {
+			r.err = r.ctx.Err()
+			return
+		}
+
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			r.err = &ObjdumpError{ExitCode: exitErr.ExitCode(), Stderr: r.stderr.String()}
+		} else if err != nil {
+			r.err = errors.Wrap(err, "could not wait for objdump")
+		}
+	})
+
+	return r.err
+}
+
+// limitedBuffer keeps the first limit bytes written into it, discarding the rest.
+type limitedBuffer struct {
+	buf   bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if remaining := b.limit - b.buf.Len(); remaining > 0 {
+		if len(p) > remaining {
+			b.buf.Write(p[:remaining])
+		} else {
+			b.buf.Write(p)
+		}
+	}
+
+	return len(p), nil
+}
 
-	return output, err
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
 }
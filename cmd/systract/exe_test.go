@@ -2,12 +2,17 @@ package systract
 
 import (
 	"bufio"
+	"context"
 	"io/ioutil"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/pjbgf/go-test/should"
+	"github.com/pkg/errors"
 )
 
 func TestExeReader_GetReader_Integration(t *testing.T) {
@@ -51,7 +56,7 @@ func TestGetFileDumpReader(t *testing.T) {
 		expectedErr error) {
 		should := should.New(t)
 
-		reader, err := getFileDumpReader(objDumpPath, input)
+		reader, err := getFileDumpReader(context.Background(), objDumpPath, input)
 		scanner := bufio.NewScanner(reader)
 		scanner.Scan()
 		actual := scanner.Text()
@@ -64,5 +69,75 @@ func TestGetFileDumpReader(t *testing.T) {
 	}
 
 	assertThat("should support custom objDump path", "/bin/echo", "123456", "123456", nil)
-	assertThat("should fallback to default if path does not exist", "/bin/echo1", "../../test/simple-app", "TEXT internal/cpu.Initialize(SB)", nil)
+	assertThat("should fallback to native disassembly if objdump does not exist", "/bin/echo1", "../../test/simple-app", "TEXT internal/cpu.Initialize(SB)", nil)
+	assertThat("should fallback to native disassembly if go is not installed", "", "../../test/simple-app", "TEXT internal/cpu.Initialize(SB)", nil)
+}
+
+func TestLookupObjDumpFilePath(t *testing.T) {
+	should := should.New(t)
+
+	toolDir, err := exec.Command("go", "env", "GOTOOLDIR").Output()
+	should.NotError(err, "should get GOTOOLDIR")
+	should.BeEqual(filepath.Join(strings.TrimSpace(string(toolDir)), "objdump"), lookupObjDumpFilePath(),
+		"should find objdump within GOTOOLDIR")
+
+	pathSnapshot := os.Getenv("PATH")
+	os.Setenv("PATH", "")
+	defer os.Setenv("PATH", pathSnapshot)
+
+	should.BeEqual("", lookupObjDumpFilePath(), "should be empty if go is not installed")
+}
+
+func TestGetFileDumpReader_Errors(t *testing.T) {
+	assertThat := func(assumption, script string, timeout time.Duration, expectedErr error) {
+		should := should.New(t)
+		dir, err := ioutil.TempDir("", "objdump-test")
+		if err != nil {
+			t.Fatalf("could not create temp dir: %s", err)
+		}
+		defer os.RemoveAll(dir)
+		objDumpPath := filepath.Join(dir, "objdump")
+		if err := ioutil.WriteFile(objDumpPath, []byte("#!/bin/sh\n"+script), 0700); err != nil {
+			t.Fatalf("could not write objdump script: %s", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		reader, err := getFileDumpReader(ctx, objDumpPath, "app")
+		should.NotError(err, assumption)
+		_, err = ioutil.ReadAll(reader)
+		reader.Close()
+
+		should.BeEqual(expectedErr, errors.Cause(err), assumption)
+	}
+
+	assertThat("should return objdump exit code and stderr",
+		"echo TEXT main.main\necho 'objdump: not an executable' >&2\nexit 3", time.Minute,
+		&ObjdumpError{ExitCode: 3, Stderr: "objdump: not an executable\n"})
+	assertThat("should not error when objdump succeeds", "echo TEXT main.main", time.Minute, nil)
+	assertThat("should stop objdump once context is done", "sleep 60", 100*time.Millisecond,
+		context.DeadlineExceeded)
+}
+
+func TestObjdumpError(t *testing.T) {
+	should := should.New(t)
+
+	should.BeEqual("objdump exited with code 1: bad file",
+		(&ObjdumpError{ExitCode: 1, Stderr: "bad file\n"}).Error(), "should include stderr")
+	should.BeEqual("objdump exited with code 2",
+		(&ObjdumpError{ExitCode: 2}).Error(), "should only include exit code when stderr is empty")
+	should.BeTrue(errors.Is(errors.Wrap(&ObjdumpError{ExitCode: 1}, "could not read dump"), ErrObjdumpFailed),
+		"should be identified as ErrObjdumpFailed")
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	should := should.New(t)
+	b := &limitedBuffer{limit: 5}
+
+	n, err := b.Write([]byte("abc"))
+	should.NotError(err, "should write")
+	should.BeEqual(3, n, "should report all bytes as written")
+	b.Write([]byte("defgh"))
+
+	should.BeEqual("abcde", b.String(), "should discard bytes above limit")
 }
@@ -0,0 +1,206 @@
+package systract
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// GraphFormat defines the format call graphs are written in.
+type GraphFormat string
+
+const (
+	// GraphDOT is the graphviz format.
+	GraphDOT GraphFormat = "dot"
+	// GraphML is the XML format supported by tools such as Gephi.
+	GraphML GraphFormat = "graphml"
+	// GraphJSON lists each symbol with its calls and system calls.
+	GraphJSON GraphFormat = "json"
+)
+
+// GraphFormats are all the formats supported.
+var GraphFormats = []GraphFormat{GraphDOT, GraphML, GraphJSON}
+
+// graphSymbol is the JSON representation of a symbol.
+type graphSymbol struct {
+	Name     string         `json:"name"`
+	Calls    []string       `json:"calls,omitempty"`
+	Syscalls []graphSyscall `json:"syscalls,omitempty"`
+}
+
+type graphSyscall struct {
+	ID   uint16 `json:"id"`
+	Name string `json:"name,omitempty"`
+}
+
+// Write serialises the graph into output. System calls are written as nodes
+// called by the symbols that make them, except for GraphJSON, in which they
+// are listed within each symbol.
+func (g *CallGraph) Write(output io.Writer, format GraphFormat) error {
+	switch format {
+	case GraphDOT:
+		return g.writeDOT(output)
+	case GraphML:
+		return g.writeGraphML(output)
+	case GraphJSON:
+		return g.writeJSON(output)
+	}
+
+	return errors.Errorf("invalid graph format: %s", format)
+}
+
+func (g *CallGraph) writeDOT(output io.Writer) error {
+	w := newErrWriter(output)
+	w.printf("digraph callgraph {\n")
+	for i, name := range g.graph.names {
+		w.printf("\t%q;\n", name)
+		for _, target := range g.graph.calls[i] {
+			w.printf("\t%q -> %q;\n", name, g.graph.names[target])
+		}
+		for _, id := range g.graph.syscalls[i] {
+			w.printf("\t%q -> %q;\n", name, syscallNodeID(id))
+		}
+	}
+	for _, id := range g.syscallIDs() {
+		w.printf("\t%q [shape=box, label=%q];\n", syscallNodeID(id), g.syscallLabel(id))
+	}
+	w.printf("}\n")
+
+	return w.err
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLEdge struct {
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+func (g *CallGraph) writeGraphML(output io.Writer) error {
+	doc := graphML{XMLNS: "http://graphml.graphdrawing.org/xmlns", Keys: []graphMLKey{
+		{ID: "label", For: "node", Name: "label", Type: "string"},
+		{ID: "kind", For: "node", Name: "kind", Type: "string"},
+	}}
+	doc.Graph.EdgeDefault = "directed"
+
+	for i, name := range g.graph.names {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: name,
+			Data: []graphMLData{{Key: "label", Value: name}, {Key: "kind", Value: "symbol"}}})
+		for _, target := range g.graph.calls[i] {
+			doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: name, Target: g.graph.names[target]})
+		}
+		for _, id := range g.graph.syscalls[i] {
+			doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{Source: name, Target: syscallNodeID(id)})
+		}
+	}
+	for _, id := range g.syscallIDs() {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{ID: syscallNodeID(id),
+			Data: []graphMLData{{Key: "label", Value: g.syscallLabel(id)}, {Key: "kind", Value: "syscall"}}})
+	}
+
+	if _, err := io.WriteString(output, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(output)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(output, "\n")
+	return err
+}
+
+func (g *CallGraph) writeJSON(output io.Writer) error {
+	symbols := make([]graphSymbol, 0, g.graph.len())
+	for i, name := range g.graph.names {
+		s := graphSymbol{Name: name}
+		for _, target := range g.graph.calls[i] {
+			s.Calls = append(s.Calls, g.graph.names[target])
+		}
+		for _, id := range g.graph.syscalls[i] {
+			s.Syscalls = append(s.Syscalls, graphSyscall{ID: id, Name: g.table[id]})
+		}
+		symbols = append(symbols, s)
+	}
+
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(struct {
+		Symbols []graphSymbol `json:"symbols"`
+	}{symbols})
+}
+
+// syscallIDs returns the IDs of all system calls in the graph, in the order they were found.
+func (g *CallGraph) syscallIDs() []uint16 {
+	var ids []uint16
+	for _, syscalls := range g.graph.syscalls {
+		for _, id := range syscalls {
+			if !containsSyscallID(ids, id) {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	return ids
+}
+
+// syscallNodeID is prefixed, so system calls cannot clash with symbol names.
+func syscallNodeID(id uint16) string {
+	return "syscall:" + strconv.Itoa(int(id))
+}
+
+func (g *CallGraph) syscallLabel(id uint16) string {
+	if name, exists := g.table[id]; exists {
+		return fmt.Sprintf("%s (%d)", name, id)
+	}
+
+	return strconv.Itoa(int(id))
+}
+
+// errWriter keeps the first error found writing into output, so it can be checked once.
+type errWriter struct {
+	output io.Writer
+	err    error
+}
+
+func newErrWriter(output io.Writer) *errWriter {
+	return &errWriter{output: output}
+}
+
+func (w *errWriter) printf(format string, args ...interface{}) {
+	if w.err == nil {
+		_, w.err = fmt.Fprintf(w.output, format, args...)
+	}
+}
@@ -0,0 +1,76 @@
+package systract
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestCallGraph_Write(t *testing.T) {
+	assertThat := func(assumption string, format GraphFormat, expected string, expectedErr bool) {
+		should := should.New(t)
+		graph := newCallGraph()
+		graph.add("main.main", symbolDefinition{subCalls: []string{"os.Exit"}})
+		graph.add("os.Exit", symbolDefinition{syscallIDs: []uint16{231}})
+		var output bytes.Buffer
+
+		err := (&CallGraph{graph: graph, table: amd64SystemCalls}).Write(&output, format)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, output.String(), assumption)
+	}
+
+	assertThat("should write dot graphs", GraphDOT, `digraph callgraph {
+	"main.main";
+	"main.main" -> "os.Exit";
+	"os.Exit";
+	"os.Exit" -> "syscall:231";
+	"syscall:231" [shape=box, label="exit_group (231)"];
+}
+`, false)
+	assertThat("should write graphml graphs", GraphML, `<?xml version="1.0" encoding="UTF-8"?>
+<graphml xmlns="http://graphml.graphdrawing.org/xmlns">
+  <key id="label" for="node" attr.name="label" attr.type="string"></key>
+  <key id="kind" for="node" attr.name="kind" attr.type="string"></key>
+  <graph edgedefault="directed">
+    <node id="main.main">
+      <data key="label">main.main</data>
+      <data key="kind">symbol</data>
+    </node>
+    <node id="os.Exit">
+      <data key="label">os.Exit</data>
+      <data key="kind">symbol</data>
+    </node>
+    <node id="syscall:231">
+      <data key="label">exit_group (231)</data>
+      <data key="kind">syscall</data>
+    </node>
+    <edge source="main.main" target="os.Exit"></edge>
+    <edge source="os.Exit" target="syscall:231"></edge>
+  </graph>
+</graphml>
+`, false)
+	assertThat("should write json graphs", GraphJSON, `{
+  "symbols": [
+    {
+      "name": "main.main",
+      "calls": [
+        "os.Exit"
+      ]
+    },
+    {
+      "name": "os.Exit",
+      "syscalls": [
+        {
+          "id": 231,
+          "name": "exit_group"
+        }
+      ]
+    }
+  ]
+}
+`, false)
+	assertThat("should error for invalid formats", GraphFormat("svg"), "", true)
+}
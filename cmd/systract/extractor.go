@@ -0,0 +1,203 @@
+package systract
+
+import (
+	"context"
+	"fmt"
+)
+
+// Extractor extracts the system calls of go applications. It holds no state other than
+// the options it was created with, so it can be reused and used concurrently.
+type Extractor struct {
+	options *options
+}
+
+// Result represents the outcome of an extraction.
+type Result struct {
+	Syscalls []SystemCall `json:"syscalls" yaml:"syscalls"`
+	// Graph is the call graph the system calls were extracted from.
+	Graph *CallGraph `json:"-" yaml:"-"`
+	// Warnings describe conditions in which system calls may have been missed.
+	Warnings []Warning `json:"warnings,omitempty" yaml:"warnings,omitempty"`
+	Metadata Metadata  `json:"metadata" yaml:"metadata"`
+}
+
+// Metadata represents the information about the application an extraction was based on.
+type Metadata struct {
+	Architecture string `json:"architecture" yaml:"architecture"`
+	// GoVersion is empty when the go release cannot be detected from the source.
+	GoVersion string `json:"goVersion,omitempty" yaml:"goVersion,omitempty"`
+	// EntryPoints are the symbols the call graph was walked from.
+	EntryPoints []string   `json:"entryPoints" yaml:"entryPoints"`
+	BuildInfo   *BuildInfo `json:"buildInfo,omitempty" yaml:"buildInfo,omitempty"`
+}
+
+// NewExtractor returns an Extractor configured with opts.
+func NewExtractor(opts ...Option) *Extractor {
+	return &Extractor{options: newOptions(opts)}
+}
+
+// Extract returns all system calls made in the execution path of the application read by source.
+// The system call IDs are resolved against the architecture detected from source,
+// unless it is overridden with WithArchitecture.
+// Calls to the functions in the catalog, DefaultCatalog unless WithCatalog is used, are handled as
+// system calls, narrowed down to the go release detected from source, unless it is overridden with WithGoVersion.
+// With WithCgo, the system calls made by the libc functions imported by source are also included.
+// With WithRuntimeRoots, the system calls made by the go runtime are also included.
+// Indirect calls are handled as defined by WithIndirectCalls.
+// The conditions in which system calls may have been missed are returned as warnings,
+// such as system calls whose number cannot be resolved, unless WithStrict is used,
+// in which case the ones that are not informational are returned as a WarningsError instead.
+// The system calls are returned in the same order for every extraction of source, as defined by WithSort.
+// Extraction stops once ctx is done, returning its error.
+func (e *Extractor) Extract(ctx context.Context, source SourceReader) (*Result, error) {
+	o := e.options
+	if err := isValidIndirectCallMode(o.indirectCalls); err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	warn := func(kind WarningKind, format string, args ...interface{}) {
+		result.Warnings = append(result.Warnings, Warning{Kind: kind, Message: fmt.Sprintf(format, args...)})
+	}
+
+	arch := o.arch
+	if arch == "" {
+		detected, err := DetectArchitecture(source)
+		if err != nil {
+			return nil, err
+		}
+		arch = detected
+	}
+
+	table, err := getSyscallTable(arch)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, err := getReader(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	goVersion := o.goVersion
+	if goVersion == "" {
+		goVersion, err = DetectGoVersion(source)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if goVersion == "" {
+		warn(WarningSource, "could not detect the go version, syscall wrappers of all go releases are used")
+	}
+
+	buildInfo, err := DetectBuildInfo(source)
+	if err != nil {
+		return nil, err
+	}
+
+	stubs, err := getLinkerStubs(source)
+	if err != nil {
+		return nil, err
+	}
+
+	wrappers := o.catalog.ForGoVersion(goVersion).index()
+	parser := &dumpParser{
+		abi:        abiSpecs[arch],
+		wrappers:   wrappers,
+		table:      table,
+		jobs:       o.jobs,
+		progress:   o.progress,
+		references: o.indirectCalls == IndirectCallsConservative,
+		stubs:      stubs,
+	}
+	graph, err := parser.parse(ctx, reader)
+	if err != nil {
+		return nil, err
+	}
+	entryPoints, err := getEntryPoints(graph, o.entryPoints)
+	if err != nil {
+		return nil, err
+	}
+	if len(o.entryPoints) == 0 && !anyInGraph(graph, DefaultEntryPoints) {
+		warn(WarningEntryPoints, "none of the default entry points were found, custom ones can be set with WithEntryPoints")
+	}
+
+	syscalls, err := extractSyscalls(ctx, graph, entryPoints, table)
+	if err != nil {
+		return nil, err
+	}
+	roots := entryPoints
+
+	if o.indirectCalls == IndirectCallsConservative {
+		if _, ok := source.(AddressTakenReader); !ok {
+			warn(WarningSource, "functions whose address is stored in data cannot be read from the source, their indirect calls are not followed")
+		}
+
+		addressTaken, err := getAddressTakenFunctions(source, graph)
+		if err != nil {
+			return nil, err
+		}
+		syscalls, err = appendIndirectSyscalls(ctx, graph, syscalls, entryPoints, addressTaken, table)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots[:len(roots):len(roots)], addressTaken...)
+	}
+
+	if o.cgo {
+		if _, ok := source.(ImportedSymbolsReader); !ok {
+			warn(WarningSource, "imported symbols cannot be read from the source, system calls made through libc are not included")
+		}
+
+		syscalls, err = appendCgoSyscalls(source, syscalls, table)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if o.runtimeRoots {
+		syscalls, err = appendRuntimeSyscalls(ctx, graph, syscalls, table, goVersion)
+		if err != nil {
+			return nil, err
+		}
+		roots = append(roots[:len(roots):len(roots)], getRuntimeEntryPoints(graph)...)
+	}
+	result.Warnings = append(result.Warnings, getReachableWarnings(graph, roots, wrappers)...)
+
+	if o.logger != nil {
+		for _, w := range result.Warnings {
+			o.logger.Printf("warning: %s", w)
+		}
+	}
+	if o.strict {
+		if strict := getStrictWarnings(result.Warnings); len(strict) > 0 {
+			return nil, &WarningsError{Warnings: strict}
+		}
+	}
+
+	if err := Sort(syscalls, o.sort); err != nil {
+		return nil, err
+	}
+
+	result.Syscalls = syscalls
+	result.Graph = &CallGraph{graph: graph, table: table, roots: roots}
+	result.Metadata = Metadata{
+		Architecture: arch,
+		GoVersion:    goVersion,
+		EntryPoints:  entryPoints,
+		BuildInfo:    buildInfo,
+	}
+
+	return result, nil
+}
+
+func anyInGraph(graph *callGraph, symbols []string) bool {
+	for _, s := range symbols {
+		if _, exists := graph.lookup(s); exists {
+			return true
+		}
+	}
+
+	return false
+}
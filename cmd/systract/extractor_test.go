@@ -0,0 +1,127 @@
+package systract
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+type testLogger struct {
+	lines []string
+}
+
+func (l *testLogger) Printf(format string, v ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf(format, v...))
+}
+
+func TestExtractor_Extract(t *testing.T) {
+	should := should.New(t)
+	extractor := NewExtractor(WithSort(SortByID))
+
+	result, err := extractor.Extract(context.Background(), NewExeReader("../../test/simple-app"))
+	syscalls, _ := Extract(NewExeReader("../../test/simple-app"), WithSort(SortByID))
+
+	should.NotError(err, "should extract syscalls")
+	should.BeEqual(syscalls, result.Syscalls, "should return the same syscalls as Extract")
+	should.BeEqual("amd64", result.Metadata.Architecture, "should return architecture")
+	should.BeEqual(true, result.Metadata.GoVersion != "", "should return go version")
+	should.BeEqual("main.main", result.Metadata.EntryPoints[0], "should return entry points")
+	should.BeEqual(true, len(result.Graph.Calls("main.main")) > 0, "should return call graph")
+	should.BeEqual(true, len(result.Graph.Roots()) > len(result.Metadata.EntryPoints),
+		"should return the roots of the call graph, including the functions whose address is taken")
+	should.BeEqual([]Warning(nil), result.Warnings, "should not return warnings")
+}
+
+func TestExtractor_Warnings(t *testing.T) {
+	assertThat := func(assumption, fileName string, expected []Warning) {
+		should := should.New(t)
+		logger := &testLogger{}
+		extractor := NewExtractor(WithArchitecture("amd64"), WithLogger(logger))
+
+		result, err := extractor.Extract(context.Background(), NewDumpReader(fileName))
+
+		should.NotError(err, assumption)
+		should.BeEqual(expected, result.Warnings, assumption)
+		lines := make([]string, 0, len(expected))
+		for _, w := range expected {
+			lines = append(lines, "warning: "+w.String())
+		}
+		should.BeEqual(lines, logger.lines, assumption)
+	}
+
+	goVersion := Warning{Kind: WarningSource,
+		Message: "could not detect the go version, syscall wrappers of all go releases are used"}
+	addressTaken := Warning{Kind: WarningSource,
+		Message: "functions whose address is stored in data cannot be read from the source, their indirect calls are not followed"}
+
+	assertThat("should warn about information missing from dumps", "../../test/single-syscall.dump",
+		[]Warning{goVersion, addressTaken})
+	assertThat("should warn when default entry points are not found", "../../test/systrac.dump", []Warning{
+		goVersion,
+		{Kind: WarningEntryPoints,
+			Message: "none of the default entry points were found, custom ones can be set with WithEntryPoints"},
+		addressTaken,
+	})
+}
+
+func TestExtractor_Strict(t *testing.T) {
+	should := should.New(t)
+	extractor := NewExtractor(WithArchitecture("amd64"), WithStrict())
+	dir, err := ioutil.TempDir("", "gosystract-test")
+	if err != nil {
+		t.Fatalf("could not setup test properly, got error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+	dumpPath := filepath.Join(dir, "unresolved.dump")
+	dump := "TEXT main.main(SB) main.go\n  main.go:1\t0x10\t488b442408\tMOVQ 0x8(SP), AX\n" +
+		"  main.go:2\t0x15\t0f05\tSYSCALL\n\n"
+	if err := ioutil.WriteFile(dumpPath, []byte(dump), 0600); err != nil {
+		t.Fatalf("could not setup test properly, got error: %s", err)
+	}
+
+	result, err := extractor.Extract(context.Background(), NewDumpReader(dumpPath))
+
+	should.BeNil(result, "should not return result when warnings are raised")
+	should.HaveSameType(&WarningsError{}, err, "should return WarningsError")
+	if warningsErr, ok := err.(*WarningsError); ok {
+		should.BeEqual([]Warning{{Kind: WarningUnresolvedSyscall, Message: "could not resolve the system call number",
+			Symbol: "main.main", Location: "main.go:2", Address: "0x15"}}, warningsErr.Warnings,
+			"should only return warnings which are not informational")
+	}
+
+	result, err = extractor.Extract(context.Background(), NewDumpReader("../../test/single-syscall.dump"))
+
+	should.NotError(err, "should not fail for informational warnings")
+	should.BeEqual(2, len(result.Warnings), "should still return informational warnings")
+}
+
+func TestExtractor_Catalog(t *testing.T) {
+	should := should.New(t)
+	source := NewExeReader("../../test/simple-app")
+
+	withDefault, err := NewExtractor().Extract(context.Background(), source)
+	should.NotError(err, "should extract syscalls with default catalog")
+	withEmpty, err := NewExtractor(WithCatalog(SyscallCatalog{})).Extract(context.Background(), source)
+	should.NotError(err, "should extract syscalls with empty catalog")
+
+	should.BeEqual(true, len(withEmpty.Syscalls) < len(withDefault.Syscalls),
+		"should only resolve calls to the wrappers in the catalog")
+}
+
+func TestCallGraph(t *testing.T) {
+	should := should.New(t)
+	graph := &CallGraph{graph: graphOf(map[string]symbolDefinition{
+		"main.main": {subCalls: []string{"os.Exit"}},
+		"os.Exit":   {syscallIDs: []uint16{231}},
+	})}
+
+	should.BeEqual(2, len(graph.Symbols()), "should list all symbols")
+	should.BeEqual([]string{"os.Exit"}, graph.Calls("main.main"), "should list calls")
+	should.BeEqual([]uint16{231}, graph.SyscallIDs("os.Exit"), "should list syscall ids")
+	should.BeEqual([]uint16(nil), graph.SyscallIDs("unknown"), "should return no syscall ids for unknown symbols")
+}
@@ -0,0 +1,301 @@
+package systract
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// callGraph is a compact representation of the functions found in a dump.
+// Symbol names are interned, so each name is held in memory only once,
+// and calls reference their targets by index.
+type callGraph struct {
+	names []string
+	index map[string]int32
+	// syscalls and calls hold the syscall IDs and call targets of each symbol,
+	// and are empty for symbols that are only referenced as call targets.
+	syscalls [][]uint16
+	calls    [][]int32
+	// warnings are only held for the symbols that raised any.
+	warnings map[int32][]Warning
+}
+
+// symbolRange holds the addresses of the first and last instructions of a symbol.
+type symbolRange struct {
+	start, end uint64
+	name       string
+}
+
+type symbolAddressCalls struct {
+	caller int32
+	calls  []addressCall
+}
+
+func newCallGraph() *callGraph {
+	return &callGraph{index: make(map[string]int32), warnings: make(map[int32][]Warning)}
+}
+
+// intern returns the index of name, adding it to the graph when it is not known yet.
+// The name is copied, as it often references the whole line it was captured from.
+func (g *callGraph) intern(name string) int32 {
+	if i, exists := g.index[name]; exists {
+		return i
+	}
+
+	name = strings.Clone(name)
+	i := int32(len(g.names))
+	g.index[name] = i
+	g.names = append(g.names, name)
+	g.syscalls = append(g.syscalls, nil)
+	g.calls = append(g.calls, nil)
+
+	return i
+}
+
+// add defines the syscall IDs and calls of the symbol name. Duplicated calls are ignored.
+func (g *callGraph) add(name string, s symbolDefinition) {
+	i := g.intern(name)
+
+	var calls []int32
+	if len(s.subCalls) > 0 {
+		calls = make([]int32, 0, len(s.subCalls))
+		seen := make(map[int32]bool, len(s.subCalls))
+		for _, subCall := range s.subCalls {
+			target := g.intern(subCall)
+			if !seen[target] {
+				seen[target] = true
+				calls = append(calls, target)
+			}
+		}
+	}
+
+	var syscallIDs []uint16
+	if len(s.syscallIDs) > 0 {
+		syscallIDs = append(make([]uint16, 0, len(s.syscallIDs)), s.syscallIDs...)
+	}
+
+	g.syscalls[i] = syscallIDs
+	g.calls[i] = calls
+
+	if len(s.warnings) > 0 {
+		warnings := make([]Warning, 0, len(s.warnings))
+		for _, w := range s.warnings {
+			w.Symbol = g.names[i]
+			warnings = append(warnings, w)
+		}
+		g.warnings[i] = warnings
+	}
+}
+
+// linkAddressCalls links calls into addresses to the symbols that contain them, such as
+// calls into the middle of runtime.duffzero. Calls into stubs, such as the .plt entries
+// of cgo executables, lead into shared libraries and are ignored. Calls outside of all
+// symbols are kept as warnings.
+func (g *callGraph) linkAddressCalls(ranges []symbolRange, addressCalls []symbolAddressCalls, stubs []AddressRange) {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+
+	for _, c := range addressCalls {
+		for _, call := range c.calls {
+			if inAnyRange(stubs, call.target) {
+				continue
+			}
+
+			r := sort.Search(len(ranges), func(i int) bool { return ranges[i].start > call.target }) - 1
+			if r < 0 || call.target > ranges[r].end {
+				g.warnings[c.caller] = append(g.warnings[c.caller], Warning{
+					Kind:     WarningUnresolvedCall,
+					Message:  fmt.Sprintf("could not resolve the call target %#x", call.target),
+					Symbol:   g.names[c.caller],
+					Location: call.location,
+					Address:  call.address,
+				})
+				continue
+			}
+
+			target := g.intern(ranges[r].name)
+			if !containsIndex(g.calls[c.caller], target) {
+				g.calls[c.caller] = append(g.calls[c.caller], target)
+			}
+		}
+	}
+}
+
+func (g *callGraph) lookup(name string) (int32, bool) {
+	i, exists := g.index[name]
+	return i, exists
+}
+
+// symbol returns the definition of the symbol name, with its calls resolved back into names.
+func (g *callGraph) symbol(name string) symbolDefinition {
+	i, exists := g.lookup(name)
+	if !exists {
+		return symbolDefinition{}
+	}
+
+	s := symbolDefinition{name: name, syscallIDs: g.syscalls[i]}
+	for _, target := range g.calls[i] {
+		s.subCalls = append(s.subCalls, g.names[target])
+	}
+
+	return s
+}
+
+func (g *callGraph) len() int {
+	return len(g.names)
+}
+
+// CallGraph is a read-only view of the call graph of an application. Only the functions
+// that make system calls or call other functions are defined, whilst the functions they
+// call are also listed as symbols.
+type CallGraph struct {
+	graph *callGraph
+	// table resolves the names of the system calls, when set.
+	table map[uint16]string
+	// roots are the symbols the graph was walked from, when set.
+	roots []string
+}
+
+// Roots returns the symbols the system calls were extracted from: the entry points,
+// followed by the functions whose address is taken with IndirectCallsConservative
+// and the runtime entry points with WithRuntimeRoots.
+func (g *CallGraph) Roots() []string {
+	return append([]string{}, g.roots...)
+}
+
+// Symbols returns the names of all symbols in the graph, in the order they were found.
+func (g *CallGraph) Symbols() []string {
+	return append([]string{}, g.graph.names...)
+}
+
+// Calls returns the symbols called by symbol.
+func (g *CallGraph) Calls(symbol string) []string {
+	return g.graph.symbol(symbol).subCalls
+}
+
+// SyscallIDs returns the IDs of the system calls made directly by symbol.
+func (g *CallGraph) SyscallIDs(symbol string) []uint16 {
+	return append([]uint16(nil), g.graph.symbol(symbol).syscallIDs...)
+}
+
+// Prune returns the graph of the symbols that lead to system calls. When entryPoints
+// are provided, only the symbols reachable from them are kept.
+func (g *CallGraph) Prune(entryPoints ...string) *CallGraph {
+	keep := g.graph.reachingSyscalls()
+	if len(entryPoints) > 0 {
+		for i, reachable := range reachableSymbols(g.graph, entryPoints) {
+			keep[i] = keep[i] && reachable
+		}
+	}
+
+	pruned := newCallGraph()
+	for i, name := range g.graph.names {
+		if !keep[i] {
+			continue
+		}
+
+		s := symbolDefinition{syscallIDs: g.graph.syscalls[i]}
+		for _, target := range g.graph.calls[i] {
+			if keep[target] {
+				s.subCalls = append(s.subCalls, g.graph.names[target])
+			}
+		}
+		pruned.add(name, s)
+	}
+
+	roots := make([]string, 0, len(g.roots))
+	for _, root := range g.roots {
+		if i, exists := g.graph.lookup(root); exists && keep[i] {
+			roots = append(roots, root)
+		}
+	}
+
+	return &CallGraph{graph: pruned, table: g.table, roots: roots}
+}
+
+// ByPackage returns the graph collapsed to package level, in which each package calls
+// the packages its functions call and makes the system calls its functions make.
+// Symbols without a package, such as assembly entry points, are kept as they are.
+func (g *CallGraph) ByPackage() *CallGraph {
+	packageOf := func(name string) string {
+		if pkg := PackageOf(name); pkg != "" {
+			return pkg
+		}
+		return name
+	}
+
+	order := make([]string, 0)
+	packages := make(map[string]*symbolDefinition)
+	for i, name := range g.graph.names {
+		pkg := packageOf(name)
+		s, exists := packages[pkg]
+		if !exists {
+			s = &symbolDefinition{}
+			packages[pkg] = s
+			order = append(order, pkg)
+		}
+
+		for _, id := range g.graph.syscalls[i] {
+			if !containsSyscallID(s.syscallIDs, id) {
+				s.syscallIDs = append(s.syscallIDs, id)
+			}
+		}
+		for _, target := range g.graph.calls[i] {
+			if called := packageOf(g.graph.names[target]); called != pkg {
+				s.subCalls = append(s.subCalls, called)
+			}
+		}
+	}
+
+	collapsed := newCallGraph()
+	for _, pkg := range order {
+		collapsed.add(pkg, *packages[pkg])
+	}
+
+	roots := make([]string, 0, len(g.roots))
+	for _, root := range g.roots {
+		if pkg := packageOf(root); !containsString(roots, pkg) {
+			roots = append(roots, pkg)
+		}
+	}
+
+	return &CallGraph{graph: collapsed, table: g.table, roots: roots}
+}
+
+// reachingSyscalls returns whether each symbol makes system calls or calls symbols that do.
+func (g *callGraph) reachingSyscalls() []bool {
+	callers := make([][]int32, g.len())
+	queue := make([]int32, 0)
+	for i := range g.names {
+		for _, target := range g.calls[i] {
+			callers[target] = append(callers[target], int32(i))
+		}
+		if len(g.syscalls[i]) > 0 {
+			queue = append(queue, int32(i))
+		}
+	}
+
+	reaching := make([]bool, g.len())
+	for len(queue) > 0 {
+		symbol := queue[0]
+		queue = queue[1:]
+
+		if reaching[symbol] {
+			continue
+		}
+		reaching[symbol] = true
+
+		queue = append(queue, callers[symbol]...)
+	}
+
+	return reaching
+}
+
+func containsSyscallID(ids []uint16, id uint16) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+
+	return false
+}
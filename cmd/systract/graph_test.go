@@ -0,0 +1,128 @@
+package systract
+
+import (
+	"context"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+// graphOf builds a call graph with the symbols provided.
+func graphOf(symbols map[string]symbolDefinition) *callGraph {
+	graph := newCallGraph()
+	for name, s := range symbols {
+		graph.add(name, s)
+	}
+
+	return graph
+}
+
+func TestCallGraph_Add(t *testing.T) {
+	should := should.New(t)
+	graph := newCallGraph()
+
+	graph.add("main.main", symbolDefinition{subCalls: []string{"os.Exit", "fmt.Println", "os.Exit"}})
+	graph.add("os.Exit", symbolDefinition{subCalls: []string{"syscall.Exit"}})
+	graph.add("syscall.Exit", symbolDefinition{syscallIDs: []uint16{231}})
+
+	should.BeEqual(4, graph.len(), "should intern each symbol name once")
+	should.BeEqual(symbolDefinition{name: "main.main", subCalls: []string{"os.Exit", "fmt.Println"}},
+		graph.symbol("main.main"), "should ignore duplicated calls")
+	should.BeEqual(symbolDefinition{name: "syscall.Exit", syscallIDs: []uint16{231}},
+		graph.symbol("syscall.Exit"), "should keep syscall ids")
+	should.BeEqual(symbolDefinition{name: "fmt.Println"},
+		graph.symbol("fmt.Println"), "should return empty definition for call targets not defined")
+	should.BeEqual(symbolDefinition{}, graph.symbol("unknown"), "should return empty definition for unknown symbols")
+}
+
+// newAmd64Parser returns a parser for amd64 dumps, parsing jobs functions concurrently.
+func newAmd64Parser(jobs int) *dumpParser {
+	return &dumpParser{abi: abiSpecs["amd64"], wrappers: DefaultCatalog.index(), table: amd64SystemCalls, jobs: jobs}
+}
+
+func TestDumpParser_Progress(t *testing.T) {
+	should := should.New(t)
+	var reports []Progress
+
+	dump := "TEXT main.main(SB) main.go\n  main.go:1\t0x1\t0f05\tSYSCALL\n\n" +
+		"TEXT main.f(SB) main.go\n  main.go:2\t0x2\te800000000\tCALL main.main(SB)\n"
+	parser := newAmd64Parser(2)
+	parser.progress = func(p Progress) { reports = append(reports, p) }
+	_, err := parser.parse(context.Background(), strings.NewReader(dump))
+
+	should.NotError(err, "should parse dump")
+	should.BeEqual([]Progress{{Lines: 5, Symbols: 2, Done: true}}, reports, "should report once parsing is done")
+}
+
+func TestDumpParser_LongLines(t *testing.T) {
+	should := should.New(t)
+
+	_, err := newAmd64Parser(2).parse(context.Background(), strings.NewReader(strings.Repeat("a", maxLineSize+1)))
+
+	should.Error(err, "should error when lines are longer than supported")
+}
+
+func TestDumpParser_Jobs(t *testing.T) {
+	should := should.New(t)
+	dump, err := ioutil.ReadFile("../../test/systrac.dump")
+	if err != nil {
+		t.Fatalf("could not read test dump: %s", err)
+	}
+
+	expected, err := newAmd64Parser(1).parse(context.Background(), strings.NewReader(string(dump)))
+	should.NotError(err, "should parse dump with a single worker")
+
+	for _, jobs := range []int{2, 8, 64} {
+		actual, err := newAmd64Parser(jobs).parse(context.Background(), strings.NewReader(string(dump)))
+
+		should.NotError(err, "should parse dump with multiple workers")
+		should.BeEqual(expected, actual, "should build the same call graph regardless of the number of workers")
+	}
+}
+
+func TestCallGraph_Prune(t *testing.T) {
+	assertThat := func(assumption string, entryPoints []string, expected []string, expectedRoots []string) {
+		should := should.New(t)
+		graph := &CallGraph{graph: graphOf(map[string]symbolDefinition{
+			"main.main":    {subCalls: []string{"os.Exit", "fmt.Println"}},
+			"main.unused":  {subCalls: []string{"os.Exit"}},
+			"main.init":    {subCalls: []string{"fmt.Println"}},
+			"os.Exit":      {subCalls: []string{"syscall.Exit"}},
+			"syscall.Exit": {syscallIDs: []uint16{231}},
+		}), roots: []string{"main.main", "main.init", "main.unused"}}
+
+		actual := graph.Prune(entryPoints...)
+
+		should.HaveSameItems(expected, actual.Symbols(), assumption)
+		should.BeEqual([]string{"syscall.Exit"}, actual.Calls("os.Exit"), assumption)
+		should.BeEqual(expectedRoots, actual.Roots(), assumption)
+	}
+
+	assertThat("should only keep symbols leading to syscalls", nil,
+		[]string{"main.main", "main.unused", "os.Exit", "syscall.Exit"}, []string{"main.main", "main.unused"})
+	assertThat("should only keep symbols reachable from entry points", []string{"main.main"},
+		[]string{"main.main", "os.Exit", "syscall.Exit"}, []string{"main.main"})
+}
+
+func TestCallGraph_ByPackage(t *testing.T) {
+	should := should.New(t)
+	graph := &CallGraph{graph: graphOf(map[string]symbolDefinition{
+		"main.main":        {subCalls: []string{"main.run", "os.Exit"}},
+		"main.run":         {subCalls: []string{"os.(*File).Write"}},
+		"os.(*File).Write": {syscallIDs: []uint16{1}},
+		"os.Exit":          {subCalls: []string{"syscall.Exit"}, syscallIDs: []uint16{1}},
+		"syscall.Exit":     {syscallIDs: []uint16{231}},
+		"_rt0_amd64_linux": {subCalls: []string{"runtime.rt0_go"}},
+	}), roots: []string{"main.main", "main.run", "_rt0_amd64_linux"}}
+
+	actual := graph.ByPackage()
+
+	should.HaveSameItems([]string{"main", "os", "syscall", "_rt0_amd64_linux", "runtime"}, actual.Symbols(),
+		"should collapse symbols into their packages")
+	should.BeEqual([]string{"os"}, actual.Calls("main"), "should drop calls within the same package")
+	should.BeEqual([]uint16{1}, actual.SyscallIDs("os"), "should merge syscalls of all functions")
+	should.BeEqual([]string{"runtime"}, actual.Calls("_rt0_amd64_linux"), "should keep symbols without package")
+	should.BeEqual([]string{"main", "_rt0_amd64_linux"}, actual.Roots(), "should collapse roots into their packages")
+}
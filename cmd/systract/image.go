@@ -0,0 +1,378 @@
+package systract
+
+import (
+	"archive/tar"
+	"bytes"
+	"debug/elf"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	whiteoutPrefix string = ".wh."
+	whiteoutOpaque string = ".wh..wh..opq"
+	defaultPath    string = "/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"
+	maxSymlinkHops int    = 40
+)
+
+var elfMagic = []byte(elf.ELFMAG)
+
+// ImageBinary represents a go executable found within a container image.
+type ImageBinary struct {
+	// Path is the location of the executable within the image.
+	Path string
+	// Entrypoint defines whether the executable is the one
+	// started by the entrypoint or cmd of the image.
+	Entrypoint bool
+	// Result holds the system calls of the executable, with the warnings
+	// and metadata of their extraction.
+	Result *Result
+	// Err holds why the system calls of the executable could not be extracted,
+	// in which case Result is nil.
+	Err error
+}
+
+// ExtractFunc extracts the system calls of the application read by source,
+// as ExtractResult does.
+type ExtractFunc func(source SourceReader, opts ...Option) (*Result, error)
+
+// ImageReader represents a container image reader, supporting OCI image layouts
+// and docker save tarballs stored on disk.
+type ImageReader struct {
+	imagePath string
+}
+
+// NewImageReader initialises a new ImageReader
+func NewImageReader(imagePath string) *ImageReader {
+	return &ImageReader{imagePath}
+}
+
+// Scan applies the image layers and extracts the system calls of every go executable found.
+// The executables are read through ExeReader, concurrently, up to the number of jobs defined
+// by WithJobs. When WithArchitecture is used, it also selects the image for that architecture
+// from multi-platform images. Executables that cannot be extracted do not stop the scan,
+// their error is kept in ImageBinary.Err instead.
+func (r *ImageReader) Scan(opts ...Option) ([]ImageBinary, error) {
+	return r.ScanWith(ExtractResult, opts...)
+}
+
+// ScanWith works as Scan, extracting the system calls of each executable with extract.
+func (r *ImageReader) ScanWith(extract ExtractFunc, opts ...Option) ([]ImageBinary, error) {
+	o := newOptions(opts)
+	fs, err := openImageFS(r.imagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer fs.close()
+
+	manifest, err := loadManifest(fs, o.arch)
+	if err != nil {
+		return nil, err
+	}
+
+	var config imageConfig
+	if err := readJSON(fs, manifest.config, &config); err != nil {
+		return nil, err
+	}
+
+	dir, err := ioutil.TempDir("", "gosystract-image")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create temporary directory")
+	}
+	defer os.RemoveAll(dir)
+
+	root := newImageRoot(dir)
+	for _, layer := range manifest.layers {
+		if err := root.applyLayer(fs, layer); err != nil {
+			return nil, err
+		}
+	}
+
+	paths, err := root.goExecutables()
+	if err != nil {
+		return nil, err
+	}
+
+	entrypoint := root.entrypoint(config)
+	binaries := make([]ImageBinary, len(paths))
+
+	jobs := o.jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	wg.Add(jobs)
+	for j := 0; j < jobs; j++ {
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				binaries[i] = root.extract(paths[i], extract, opts)
+				binaries[i].Entrypoint = paths[i] == entrypoint
+			}
+		}()
+	}
+	for i := range paths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	return binaries, nil
+}
+
+// imageRoot holds the executables of an image, applied layer by layer into dir.
+// Other files are not kept, although symlinks are tracked for resolving the entrypoint.
+type imageRoot struct {
+	dir      string
+	symlinks map[string]string
+}
+
+func newImageRoot(dir string) *imageRoot {
+	return &imageRoot{dir: dir, symlinks: make(map[string]string)}
+}
+
+func (r *imageRoot) diskPath(p string) string {
+	return filepath.Join(r.dir, filepath.FromSlash(p))
+}
+
+func (r *imageRoot) applyLayer(fs imageFS, layer string) error {
+	f, err := fs.open(layer)
+	if err != nil {
+		return errors.Wrapf(err, "could not open layer %s", layer)
+	}
+	defer f.Close()
+
+	reader, err := decompress(f)
+	if err != nil {
+		return err
+	}
+
+	tr := tar.NewReader(reader)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "could not read layer %s", layer)
+		}
+
+		if err := r.applyEntry(hdr, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// applyEntry applies a single layer entry, handling whiteouts as per the OCI image spec.
+// Paths are cleaned from the root, so entries cannot be written outside of dir.
+func (r *imageRoot) applyEntry(hdr *tar.Header, content io.Reader) error {
+	name := path.Clean("/" + hdr.Name)
+	dir, base := path.Split(name)
+
+	if base == whiteoutOpaque {
+		return r.removeChildren(dir)
+	}
+	if strings.HasPrefix(base, whiteoutPrefix) {
+		return r.remove(path.Join(dir, strings.TrimPrefix(base, whiteoutPrefix)))
+	}
+
+	switch hdr.Typeflag {
+	case tar.TypeDir:
+		if _, isLink := r.symlinks[name]; isLink {
+			return r.remove(name)
+		}
+		return nil
+	case tar.TypeSymlink:
+		if err := r.remove(name); err != nil {
+			return err
+		}
+		r.symlinks[name] = hdr.Linkname
+		return nil
+	case tar.TypeLink:
+		if err := r.remove(name); err != nil {
+			return err
+		}
+		target := r.diskPath(path.Clean("/" + hdr.Linkname))
+		if !fileExists(target) {
+			return nil
+		}
+		source, err := os.Open(target)
+		if err != nil {
+			return err
+		}
+		defer source.Close()
+		return r.write(name, source)
+	case tar.TypeReg:
+		if err := r.remove(name); err != nil {
+			return err
+		}
+		if hdr.FileInfo().Mode()&0111 == 0 {
+			return nil
+		}
+		return r.writeIfElf(name, content)
+	}
+
+	return r.remove(name)
+}
+
+func (r *imageRoot) writeIfElf(name string, content io.Reader) error {
+	magic := make([]byte, len(elfMagic))
+	n, _ := io.ReadFull(content, magic)
+	if !bytes.Equal(magic[:n], elfMagic) {
+		return nil
+	}
+
+	return r.write(name, io.MultiReader(bytes.NewReader(magic), content))
+}
+
+func (r *imageRoot) write(name string, content io.Reader) error {
+	target := r.diskPath(name)
+	if err := os.MkdirAll(filepath.Dir(target), 0700); err != nil {
+		return errors.Wrap(err, "could not create directory")
+	}
+
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0700)
+	if err != nil {
+		return errors.Wrap(err, "could not create file")
+	}
+	defer f.Close()
+
+	_, err = io.Copy(f, content)
+	return err
+}
+
+func (r *imageRoot) remove(name string) error {
+	for link := range r.symlinks {
+		if link == name || strings.HasPrefix(link, name+"/") {
+			delete(r.symlinks, link)
+		}
+	}
+
+	return os.RemoveAll(r.diskPath(name))
+}
+
+func (r *imageRoot) removeChildren(dir string) error {
+	dir = path.Clean(dir)
+	for link := range r.symlinks {
+		if strings.HasPrefix(link, strings.TrimSuffix(dir, "/")+"/") {
+			delete(r.symlinks, link)
+		}
+	}
+
+	entries, err := ioutil.ReadDir(r.diskPath(dir))
+	if err != nil {
+		return nil
+	}
+	for _, e := range entries {
+		if err := os.RemoveAll(filepath.Join(r.diskPath(dir), e.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// goExecutables returns the paths of all go executables in the image, sorted by name.
+func (r *imageRoot) goExecutables() ([]string, error) {
+	paths := make([]string, 0)
+	err := filepath.Walk(r.dir, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() || !isGoBinary(filePath) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(r.dir, filePath)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, "/"+filepath.ToSlash(rel))
+		return nil
+	})
+
+	return paths, errors.Wrap(err, "could not list image executables")
+}
+
+// entrypoint returns the path of the executable started by the image,
+// based on its entrypoint, or cmd when the entrypoint is not set.
+func (r *imageRoot) entrypoint(config imageConfig) string {
+	args := config.Config.Entrypoint
+	if len(args) == 0 {
+		args = config.Config.Cmd
+	}
+	if len(args) == 0 || args[0] == "" {
+		return ""
+	}
+
+	if strings.Contains(args[0], "/") {
+		return r.resolve(path.Clean("/" + args[0]))
+	}
+
+	searchPath := defaultPath
+	for _, env := range config.Config.Env {
+		if strings.HasPrefix(env, "PATH=") {
+			searchPath = strings.TrimPrefix(env, "PATH=")
+		}
+	}
+
+	for _, dir := range strings.Split(searchPath, ":") {
+		p := r.resolve(path.Join("/", dir, args[0]))
+		if fileExists(r.diskPath(p)) {
+			return p
+		}
+	}
+
+	return ""
+}
+
+// resolve follows the symlinks within p, including the ones of its parent directories.
+func (r *imageRoot) resolve(p string) string {
+	for hops := 0; hops < maxSymlinkHops; hops++ {
+		parts := strings.Split(strings.TrimPrefix(p, "/"), "/")
+		resolved := false
+
+		for i := range parts {
+			link := "/" + strings.Join(parts[:i+1], "/")
+			target, isLink := r.symlinks[link]
+			if !isLink {
+				continue
+			}
+
+			if !path.IsAbs(target) {
+				target = path.Join(path.Dir(link), target)
+			}
+			p = path.Join("/", target, strings.Join(parts[i+1:], "/"))
+			resolved = true
+			break
+		}
+
+		if !resolved {
+			return p
+		}
+	}
+
+	return p
+}
+
+func (r *imageRoot) extract(p string, extract ExtractFunc, opts []Option) ImageBinary {
+	binary := ImageBinary{Path: p}
+
+	result, err := extract(NewExeReader(r.diskPath(p)), opts...)
+	if err != nil {
+		binary.Err = errors.Wrapf(err, "could not extract syscalls from %s", p)
+		return binary
+	}
+
+	binary.Result = result
+	return binary
+}
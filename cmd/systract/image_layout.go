@@ -0,0 +1,382 @@
+package systract
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	ociIndexMediaType   string = "application/vnd.oci.image.index.v1+json"
+	dockerListMediaType string = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+	digestAlgorithm = regexp.MustCompile(`^[a-z0-9]+(?:[+._-][a-z0-9]+)*$`)
+	digestHex       = regexp.MustCompile(`^[a-f0-9]+$`)
+)
+
+// imageFS provides access to the files of an image stored on disk,
+// either extracted into a directory or within a tarball.
+type imageFS interface {
+	open(name string) (io.ReadCloser, error)
+	exists(name string) bool
+	close() error
+}
+
+// cleanImagePath returns name cleaned, rejecting the names that point outside
+// of the image, which could otherwise be read through its manifests.
+func cleanImagePath(name string) (string, error) {
+	cleaned := path.Clean(name)
+	if path.IsAbs(cleaned) || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", errors.Errorf("%s is outside of the image", name)
+	}
+
+	return cleaned, nil
+}
+
+type dirFS string
+
+func (d dirFS) open(name string) (io.ReadCloser, error) {
+	cleaned, err := cleanImagePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return os.Open(filepath.Join(string(d), filepath.FromSlash(cleaned)))
+}
+
+func (d dirFS) exists(name string) bool {
+	cleaned, err := cleanImagePath(name)
+	if err != nil {
+		return false
+	}
+
+	return fileExists(filepath.Join(string(d), filepath.FromSlash(cleaned)))
+}
+
+func (d dirFS) close() error {
+	return nil
+}
+
+// tarFS reads files from a tarball, whose entries are indexed once so they can be read
+// without scanning it again. Compressed tarballs are decompressed into a temporary file.
+type tarFS struct {
+	file    *os.File
+	entries map[string]tarIndexEntry
+	// temporary defines whether file is removed once closed.
+	temporary bool
+}
+
+// tarIndexEntry holds where the content of a tarball entry is.
+type tarIndexEntry struct {
+	offset int64
+	size   int64
+}
+
+func newTarFS(tarPath string) (*tarFS, error) {
+	f, err := os.Open(tarPath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open image")
+	}
+
+	t := &tarFS{file: f}
+	magic := make([]byte, len(zstdMagic))
+	n, _ := io.ReadFull(f, magic)
+	if bytes.HasPrefix(magic[:n], gzipMagic) || bytes.HasPrefix(magic[:n], zstdMagic) {
+		if t.file, err = decompressToTemp(f); err != nil {
+			return nil, err
+		}
+		t.temporary = true
+	}
+
+	if err := t.index(); err != nil {
+		t.close()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// decompressToTemp decompresses f into a temporary file, closing f.
+func decompressToTemp(f *os.File) (*os.File, error) {
+	defer f.Close()
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, errors.Wrap(err, "could not read image tarball")
+	}
+
+	reader, err := decompress(f)
+	if err != nil {
+		return nil, err
+	}
+
+	temp, err := ioutil.TempFile("", "gosystract-image-*.tar")
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create temporary file")
+	}
+	if _, err := io.Copy(temp, reader); err != nil {
+		temp.Close()
+		os.Remove(temp.Name())
+		return nil, errors.Wrap(err, "could not decompress image tarball")
+	}
+
+	return temp, nil
+}
+
+// index records where the content of each regular file in the tarball is.
+// The first entry is kept when names are repeated.
+func (t *tarFS) index() error {
+	if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "could not read image tarball")
+	}
+
+	t.entries = make(map[string]tarIndexEntry)
+	tr := tar.NewReader(t.file)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrap(err, "could not read image tarball")
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		name := path.Clean(hdr.Name)
+		if _, exists := t.entries[name]; exists {
+			continue
+		}
+
+		offset, err := t.file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return errors.Wrap(err, "could not read image tarball")
+		}
+		t.entries[name] = tarIndexEntry{offset: offset, size: hdr.Size}
+	}
+}
+
+func (t *tarFS) open(name string) (io.ReadCloser, error) {
+	cleaned, err := cleanImagePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	e, exists := t.entries[cleaned]
+	if !exists {
+		return nil, errors.Errorf("%s not found in %s", name, t.file.Name())
+	}
+
+	return ioutil.NopCloser(io.NewSectionReader(t.file, e.offset, e.size)), nil
+}
+
+func (t *tarFS) exists(name string) bool {
+	cleaned, err := cleanImagePath(name)
+	if err != nil {
+		return false
+	}
+
+	_, exists := t.entries[cleaned]
+	return exists
+}
+
+func (t *tarFS) close() error {
+	err := t.file.Close()
+	if t.temporary {
+		os.Remove(t.file.Name())
+	}
+
+	return err
+}
+
+func openImageFS(imagePath string) (imageFS, error) {
+	info, err := os.Stat(imagePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open image")
+	}
+
+	if info.IsDir() {
+		return dirFS(imagePath), nil
+	}
+
+	return newTarFS(imagePath)
+}
+
+// decompress handles gzip compressed content, which is commonly used for image layers.
+func decompress(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(br)
+	case bytes.HasPrefix(magic, zstdMagic):
+		return nil, errors.New("zstd compressed layers are not supported")
+	}
+
+	return br, nil
+}
+
+// imageManifest holds the location of the config and layers of an image within its imageFS.
+type imageManifest struct {
+	config string
+	layers []string
+}
+
+// imageConfig holds the fields of the image configuration used to locate its entrypoint.
+type imageConfig struct {
+	Config struct {
+		Entrypoint []string `json:"Entrypoint"`
+		Cmd        []string `json:"Cmd"`
+		Env        []string `json:"Env"`
+	} `json:"config"`
+}
+
+type dockerManifest struct {
+	Config string   `json:"Config"`
+	Layers []string `json:"Layers"`
+}
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+	Platform  *struct {
+		Architecture string `json:"architecture"`
+	} `json:"platform,omitempty"`
+}
+
+type ociIndex struct {
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Config    ociDescriptor   `json:"config"`
+	Layers    []ociDescriptor `json:"layers"`
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+// loadManifest supports docker save tarballs, through their manifest.json,
+// and OCI image layouts, through their index.json. When the image supports multiple
+// platforms, the manifest for arch is used, or the first one when arch is empty.
+func loadManifest(fs imageFS, arch string) (*imageManifest, error) {
+	if fs.exists("manifest.json") {
+		var manifests []dockerManifest
+		if err := readJSON(fs, "manifest.json", &manifests); err != nil {
+			return nil, err
+		}
+		if len(manifests) == 0 {
+			return nil, errors.New("no images found in manifest.json")
+		}
+
+		return &imageManifest{config: manifests[0].Config, layers: manifests[0].Layers}, nil
+	}
+
+	if fs.exists("index.json") {
+		var index ociIndex
+		if err := readJSON(fs, "index.json", &index); err != nil {
+			return nil, err
+		}
+
+		return loadOCIManifest(fs, index.Manifests, arch)
+	}
+
+	return nil, errors.New("unsupported image format: expected an OCI image layout or docker save tarball")
+}
+
+func loadOCIManifest(fs imageFS, descriptors []ociDescriptor, arch string) (*imageManifest, error) {
+	descriptor, found := selectDescriptor(descriptors, arch)
+	if !found {
+		return nil, errors.Errorf("no image manifest found for architecture %s", arch)
+	}
+
+	manifestPath, err := blobPath(descriptor.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest ociManifest
+	if err := readJSON(fs, manifestPath, &manifest); err != nil {
+		return nil, err
+	}
+
+	if descriptor.MediaType == ociIndexMediaType || descriptor.MediaType == dockerListMediaType ||
+		len(manifest.Manifests) > 0 {
+		return loadOCIManifest(fs, manifest.Manifests, arch)
+	}
+
+	layers := make([]string, 0, len(manifest.Layers))
+	for _, l := range manifest.Layers {
+		layer, err := blobPath(l.Digest)
+		if err != nil {
+			return nil, err
+		}
+		layers = append(layers, layer)
+	}
+
+	config, err := blobPath(manifest.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return &imageManifest{config: config, layers: layers}, nil
+}
+
+func selectDescriptor(descriptors []ociDescriptor, arch string) (ociDescriptor, bool) {
+	if len(descriptors) == 0 {
+		return ociDescriptor{}, false
+	}
+
+	if arch == "" {
+		return descriptors[0], true
+	}
+
+	for _, d := range descriptors {
+		if d.Platform == nil || d.Platform.Architecture == arch {
+			return d, true
+		}
+	}
+
+	return ociDescriptor{}, false
+}
+
+// blobPath returns the location of a blob within an OCI image layout,
+// e.g. "sha256:abc" is stored at "blobs/sha256/abc". Digests must be in the
+// algorithm:hex format, so they cannot point outside of the blobs directory.
+func blobPath(digest string) (string, error) {
+	parts := strings.SplitN(digest, ":", 2)
+	if len(parts) != 2 || !digestAlgorithm.MatchString(parts[0]) || !digestHex.MatchString(parts[1]) {
+		return "", errors.Errorf("invalid digest: %q", digest)
+	}
+
+	return path.Join("blobs", parts[0], parts[1]), nil
+}
+
+func readJSON(fs imageFS, name string, v interface{}) error {
+	r, err := fs.open(name)
+	if err != nil {
+		return errors.Wrapf(err, "could not open %s", name)
+	}
+	defer r.Close()
+
+	if err := json.NewDecoder(r).Decode(v); err != nil {
+		return errors.Wrapf(err, "could not parse %s", name)
+	}
+
+	return nil
+}
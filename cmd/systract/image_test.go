@@ -0,0 +1,358 @@
+package systract
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+type layerEntry struct {
+	name     string
+	typeflag byte
+	mode     int64
+	content  []byte
+	linkname string
+}
+
+func buildLayer(t *testing.T, compress bool, entries ...layerEntry) []byte {
+	var buf bytes.Buffer
+	var tw *tar.Writer
+	var gw *gzip.Writer
+	if compress {
+		gw = gzip.NewWriter(&buf)
+		tw = tar.NewWriter(gw)
+	} else {
+		tw = tar.NewWriter(&buf)
+	}
+
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Typeflag: e.typeflag, Mode: e.mode,
+			Size: int64(len(e.content)), Linkname: e.linkname}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("could not write layer header: %s", err)
+		}
+		if _, err := tw.Write(e.content); err != nil {
+			t.Fatalf("could not write layer content: %s", err)
+		}
+	}
+
+	tw.Close()
+	if gw != nil {
+		gw.Close()
+	}
+
+	return buf.Bytes()
+}
+
+func writeDockerArchive(t *testing.T, dir string, config []byte, layers ...[]byte) string {
+	var entries []layerEntry
+	manifest := dockerManifest{Config: "config.json"}
+	entries = append(entries, layerEntry{name: "config.json", typeflag: tar.TypeReg, mode: 0644, content: config})
+	for i, l := range layers {
+		name := fmt.Sprintf("layer%d/layer.tar", i)
+		manifest.Layers = append(manifest.Layers, name)
+		entries = append(entries, layerEntry{name: name, typeflag: tar.TypeReg, mode: 0644, content: l})
+	}
+
+	m, _ := json.Marshal([]dockerManifest{manifest})
+	entries = append(entries, layerEntry{name: "manifest.json", typeflag: tar.TypeReg, mode: 0644, content: m})
+
+	archive := filepath.Join(dir, "image.tar")
+	if err := ioutil.WriteFile(archive, buildLayer(t, false, entries...), 0600); err != nil {
+		t.Fatalf("could not write docker archive: %s", err)
+	}
+
+	return archive
+}
+
+func writeOCILayout(t *testing.T, dir string, config []byte, layers ...[]byte) string {
+	writeBlob := func(content []byte) string {
+		digest := fmt.Sprintf("sha256:%x", sha256.Sum256(content))
+		blob := filepath.Join(dir, "blobs", "sha256", strings.TrimPrefix(digest, "sha256:"))
+		os.MkdirAll(filepath.Dir(blob), 0700)
+		if err := ioutil.WriteFile(blob, content, 0600); err != nil {
+			t.Fatalf("could not write blob: %s", err)
+		}
+		return digest
+	}
+
+	manifest := ociManifest{Config: ociDescriptor{Digest: writeBlob(config)}}
+	for _, l := range layers {
+		manifest.Layers = append(manifest.Layers, ociDescriptor{Digest: writeBlob(l)})
+	}
+	m, _ := json.Marshal(manifest)
+
+	index, _ := json.Marshal(ociIndex{Manifests: []ociDescriptor{{Digest: writeBlob(m)}}})
+	ioutil.WriteFile(filepath.Join(dir, "oci-layout"), []byte(`{"imageLayoutVersion": "1.0.0"}`), 0600)
+	ioutil.WriteFile(filepath.Join(dir, "index.json"), index, 0600)
+
+	return dir
+}
+
+func TestImageReader_Scan(t *testing.T) {
+	assertThat := func(assumption, imagePath string, expected []ImageBinary, expectedErr bool) {
+		should := should.New(t)
+
+		binaries, err := NewImageReader(imagePath).Scan()
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(len(expected), len(binaries), assumption)
+		for i := range binaries {
+			should.BeEqual(expected[i].Path, binaries[i].Path, assumption)
+			should.BeEqual(expected[i].Entrypoint, binaries[i].Entrypoint, assumption)
+			should.BeEqual(expected[i].Result.Metadata.Architecture, binaries[i].Result.Metadata.Architecture, assumption)
+			should.BeEqual(expected[i].Result.Metadata.GoVersion, binaries[i].Result.Metadata.GoVersion, assumption)
+			should.BeTrue(len(binaries[i].Result.Syscalls) > 0, assumption)
+		}
+	}
+
+	app, err := ioutil.ReadFile("../../test/simple-app")
+	if err != nil {
+		t.Fatalf("could not read test executable: %s", err)
+	}
+
+	dockerDir, _ := ioutil.TempDir("", "gosystract-test")
+	defer os.RemoveAll(dockerDir)
+	dockerArchive := writeDockerArchive(t, dockerDir,
+		[]byte(`{"config": {"Entrypoint": ["/app/server"]}}`),
+		buildLayer(t, false,
+			layerEntry{name: "app/", typeflag: tar.TypeDir, mode: 0755},
+			layerEntry{name: "app/server", typeflag: tar.TypeReg, mode: 0755, content: app},
+			layerEntry{name: "app/config.yaml", typeflag: tar.TypeReg, mode: 0644, content: []byte("a: b")}),
+		buildLayer(t, false,
+			layerEntry{name: "usr/bin/helper", typeflag: tar.TypeReg, mode: 0755, content: app}),
+		buildLayer(t, false,
+			layerEntry{name: "usr/bin/.wh.helper", typeflag: tar.TypeReg, mode: 0644}))
+
+	ociDir, _ := ioutil.TempDir("", "gosystract-test")
+	defer os.RemoveAll(ociDir)
+	ociLayout := writeOCILayout(t, ociDir,
+		[]byte(`{"config": {"Cmd": ["server"], "Env": ["PATH=/usr/local/bin"]}}`),
+		buildLayer(t, true,
+			layerEntry{name: "opt/server", typeflag: tar.TypeReg, mode: 0755, content: app},
+			layerEntry{name: "usr/local/bin/server", typeflag: tar.TypeSymlink, linkname: "../../../opt/server"}))
+
+	assertThat("should scan go executables in docker save tarballs", dockerArchive,
+		[]ImageBinary{{Path: "/app/server", Entrypoint: true,
+			Result: &Result{Metadata: Metadata{Architecture: "amd64", GoVersion: "go1.13.4"}}}}, false)
+	assertThat("should scan go executables in OCI image layouts", ociLayout,
+		[]ImageBinary{{Path: "/opt/server", Entrypoint: true,
+			Result: &Result{Metadata: Metadata{Architecture: "amd64", GoVersion: "go1.13.4"}}}}, false)
+	assertThat("should error when image does not exist", "image-that-dont-exist", nil, true)
+	assertThat("should error for unsupported image formats", "../../test", nil, true)
+}
+
+func TestImageReader_ScanWith_Errors(t *testing.T) {
+	should := should.New(t)
+	app, err := ioutil.ReadFile("../../test/simple-app")
+	if err != nil {
+		t.Fatalf("could not read test executable: %s", err)
+	}
+
+	dir, _ := ioutil.TempDir("", "gosystract-test")
+	defer os.RemoveAll(dir)
+	archive := writeDockerArchive(t, dir, []byte(`{"config": {"Entrypoint": ["/app/server"]}}`),
+		buildLayer(t, false,
+			layerEntry{name: "app/server", typeflag: tar.TypeReg, mode: 0755, content: app},
+			layerEntry{name: "bin/broken", typeflag: tar.TypeReg, mode: 0755, content: app}))
+
+	binaries, err := NewImageReader(archive).ScanWith(func(source SourceReader, opts ...Option) (*Result, error) {
+		if strings.HasSuffix(source.(*ExeReader).filePath, "broken") {
+			return nil, errors.New("could not read executable")
+		}
+		return &Result{}, nil
+	})
+
+	should.NotError(err, "should not stop scanning when an executable cannot be extracted")
+	should.BeEqual(2, len(binaries), "should return all executables")
+	for _, b := range binaries {
+		if b.Path == "/bin/broken" {
+			should.BeNil(b.Result, "should not return result of failed executables")
+			should.BeEqual("could not extract syscalls from /bin/broken: could not read executable", b.Err.Error(),
+				"should keep error of failed executables")
+			continue
+		}
+		should.BeNotNil(b.Result, "should return result of other executables")
+		should.BeNil(b.Err, "should not set error of other executables")
+	}
+}
+
+func TestBlobPath(t *testing.T) {
+	assertThat := func(assumption, digest, expected string, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := blobPath(digest)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should map digest into blobs directory", "sha256:abc123", "blobs/sha256/abc123", false)
+	assertThat("should support algorithms with separators", "sha512+b64:0f", "blobs/sha512+b64/0f", false)
+	assertThat("should error for digests without algorithm", "abc123", "", true)
+	assertThat("should error for encoded parts that are not hex", "sha256:../../../etc/passwd", "", true)
+	assertThat("should error for algorithms with paths", "../sha256:abc", "", true)
+	assertThat("should error for empty encoded parts", "sha256:", "", true)
+}
+
+func TestImageFS_Open(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "gosystract-test")
+	defer os.RemoveAll(dir)
+	content := []byte(`{"a": "b"}`)
+	root := filepath.Join(dir, "layout")
+	os.MkdirAll(filepath.Join(root, "blobs"), 0700)
+	ioutil.WriteFile(filepath.Join(root, "blobs", "config.json"), content, 0600)
+	ioutil.WriteFile(filepath.Join(dir, "outside.json"), content, 0600)
+
+	tarball := buildLayer(t, false,
+		layerEntry{name: "./blobs/", typeflag: tar.TypeDir, mode: 0755},
+		layerEntry{name: "./blobs/config.json", typeflag: tar.TypeReg, mode: 0644, content: content},
+		layerEntry{name: "layer.tar", typeflag: tar.TypeReg, mode: 0644, content: []byte("layer")},
+		layerEntry{name: "blobs/config.json", typeflag: tar.TypeReg, mode: 0644, content: []byte("repeated")})
+	plain := filepath.Join(dir, "image.tar")
+	ioutil.WriteFile(plain, tarball, 0600)
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(tarball)
+	gw.Close()
+	compressed := filepath.Join(dir, "image.tar.gz")
+	ioutil.WriteFile(compressed, gz.Bytes(), 0600)
+
+	assertThat := func(assumption, imagePath, name string, expected []byte, expectedErr bool) {
+		should := should.New(t)
+		fs, err := openImageFS(imagePath)
+		if err != nil {
+			t.Fatalf("could not open image: %s", err)
+		}
+		defer fs.close()
+
+		r, err := fs.open(name)
+		var actual []byte
+		if err == nil {
+			actual, err = ioutil.ReadAll(r)
+			r.Close()
+		}
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+		should.BeEqual(!expectedErr, fs.exists(name), assumption)
+	}
+
+	for _, image := range []string{root, plain, compressed} {
+		assertThat("should open files within the image", image, "blobs/config.json", content, false)
+		assertThat("should clean names", image, "./blobs/../blobs/config.json", content, false)
+		assertThat("should reject names outside of the image", image, "../outside.json", []byte(nil), true)
+		assertThat("should reject absolute names", image, filepath.Join(dir, "outside.json"), []byte(nil), true)
+		assertThat("should error for missing files", image, "blobs/missing.json", []byte(nil), true)
+	}
+	assertThat("should open any entry of tarballs", plain, "layer.tar", []byte("layer"), false)
+	assertThat("should open entries of compressed tarballs", compressed, "layer.tar", []byte("layer"), false)
+}
+
+func TestTarFS_Close(t *testing.T) {
+	should := should.New(t)
+	dir, _ := ioutil.TempDir("", "gosystract-test")
+	defer os.RemoveAll(dir)
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	gw.Write(buildLayer(t, false, layerEntry{name: "a", typeflag: tar.TypeReg, mode: 0644, content: []byte("a")}))
+	gw.Close()
+	compressed := filepath.Join(dir, "image.tar.gz")
+	ioutil.WriteFile(compressed, gz.Bytes(), 0600)
+
+	fs, err := newTarFS(compressed)
+	should.NotError(err, "should open compressed tarball")
+	temp := fs.file.Name()
+
+	should.NotError(fs.close(), "should close tarball")
+	should.BeFalse(fileExists(temp), "should remove decompressed tarball")
+	should.BeTrue(fileExists(compressed), "should keep original tarball")
+}
+
+func TestImageRoot_ApplyEntry(t *testing.T) {
+	assertThat := func(assumption string, entries []layerEntry, config string, expectedFiles []string, expectedEntrypoint string) {
+		should := should.New(t)
+		dir, _ := ioutil.TempDir("", "gosystract-test")
+		defer os.RemoveAll(dir)
+		root := newImageRoot(dir)
+
+		for _, e := range entries {
+			hdr := &tar.Header{Name: e.name, Typeflag: e.typeflag, Mode: e.mode, Linkname: e.linkname}
+			err := root.applyEntry(hdr, bytes.NewReader(e.content))
+			should.NotError(err, assumption)
+		}
+
+		files := make([]string, 0)
+		filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+			if err == nil && info.Mode().IsRegular() {
+				rel, _ := filepath.Rel(dir, p)
+				files = append(files, "/"+filepath.ToSlash(rel))
+			}
+			return nil
+		})
+
+		var c imageConfig
+		json.Unmarshal([]byte(config), &c)
+
+		should.BeEqual(expectedFiles, files, assumption)
+		should.BeEqual(expectedEntrypoint, root.entrypoint(c), assumption)
+	}
+
+	elfFile := []byte("\x7fELF fake")
+	exe := func(name string) layerEntry {
+		return layerEntry{name: name, typeflag: tar.TypeReg, mode: 0755, content: elfFile}
+	}
+
+	assertThat("should only keep elf executables",
+		[]layerEntry{
+			exe("bin/app"),
+			{name: "bin/script.sh", typeflag: tar.TypeReg, mode: 0755, content: []byte("#!/bin/sh")},
+			{name: "lib/lib.so", typeflag: tar.TypeReg, mode: 0644, content: elfFile},
+		},
+		`{"config": {"Entrypoint": ["/bin/app"]}}`, []string{"/bin/app"}, "/bin/app")
+	assertThat("should remove whiteout files",
+		[]layerEntry{exe("bin/app"), exe("bin/other"), {name: "bin/.wh.app", typeflag: tar.TypeReg}},
+		`{"config": {"Entrypoint": ["/bin/app"]}}`, []string{"/bin/other"}, "/bin/app")
+	assertThat("should remove children of opaque directories",
+		[]layerEntry{exe("bin/app"), exe("opt/tool"), {name: "bin/.wh..wh..opq", typeflag: tar.TypeReg}},
+		`{}`, []string{"/opt/tool"}, "")
+	assertThat("should replace executables overwritten by other files",
+		[]layerEntry{exe("bin/app"), {name: "bin/app", typeflag: tar.TypeReg, mode: 0644}},
+		`{}`, []string{}, "")
+	assertThat("should copy hard links",
+		[]layerEntry{exe("bin/app"), {name: "bin/link", typeflag: tar.TypeLink, linkname: "bin/app"}},
+		`{}`, []string{"/bin/app", "/bin/link"}, "")
+	assertThat("should not write outside of root",
+		[]layerEntry{exe("../../escape")},
+		`{}`, []string{"/escape"}, "")
+	assertThat("should find entrypoint in default path",
+		[]layerEntry{exe("usr/bin/app")},
+		`{"config": {"Cmd": ["app", "--flag"]}}`, []string{"/usr/bin/app"}, "/usr/bin/app")
+	assertThat("should resolve symlinks of entrypoint and parent directories",
+		[]layerEntry{
+			exe("opt/v1/app"),
+			{name: "opt/current", typeflag: tar.TypeSymlink, linkname: "v1"},
+			{name: "bin/app", typeflag: tar.TypeSymlink, linkname: "/opt/current/app"},
+		},
+		`{"config": {"Entrypoint": ["/bin/app"]}}`, []string{"/opt/v1/app"}, "/opt/v1/app")
+	assertThat("should not loop forever on symlink cycles",
+		[]layerEntry{
+			{name: "bin/a", typeflag: tar.TypeSymlink, linkname: "b"},
+			{name: "bin/b", typeflag: tar.TypeSymlink, linkname: "a"},
+		},
+		`{"config": {"Entrypoint": ["/bin/a"]}}`, []string{}, "/bin/a")
+}
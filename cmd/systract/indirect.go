@@ -0,0 +1,276 @@
+package systract
+
+import (
+	"context"
+	"debug/elf"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// IndirectCallMode defines how calls through interfaces and function values are handled.
+type IndirectCallMode string
+
+const (
+	// IndirectCallsConservative treats functions whose address is taken as potentially reachable.
+	// Functions referenced by the code of another function, such as closures, are handled as
+	// called by it, and so are the methods of the types in the itabs it references. Functions whose
+	// address is stored in the data of the executable, such as in itabs or package variables,
+	// are walked as entry points. This is the default mode.
+	IndirectCallsConservative IndirectCallMode = "conservative"
+	// IndirectCallsDirect only follows direct calls, ignoring indirect calls.
+	// It was the behaviour of previous releases, before IndirectCallsConservative became the default.
+	IndirectCallsDirect IndirectCallMode = "direct"
+	// IndirectCallsStrict is the former name of IndirectCallsDirect, which it is handled as.
+	//
+	// Deprecated: use IndirectCallsDirect instead.
+	IndirectCallsStrict IndirectCallMode = "strict"
+)
+
+// IndirectCallModes are all the modes supported.
+var IndirectCallModes = []IndirectCallMode{IndirectCallsConservative, IndirectCallsDirect}
+
+// AddressTakenReader defines the interface for sources that can list the functions
+// whose address is stored in the data of the application they read.
+type AddressTakenReader interface {
+	AddressTakenFunctions() ([]string, error)
+}
+
+const referenceRegex string = "([^\\s$(]+?)(?:[+-](?:0x)?[0-9a-f]+)?\\(SB\\)"
+
+var reference = regexp.MustCompile(referenceRegex)
+
+// itabPrefixes are the prefixes of the itab symbols, which changed in go 1.20.
+var itabPrefixes = []string{"go:itab.", "go.itab."}
+
+// ParseIndirectCallMode returns the mode named value, resolving deprecated names into
+// the mode they are an alias of.
+func ParseIndirectCallMode(value string) (IndirectCallMode, error) {
+	mode := IndirectCallMode(value)
+	if mode == IndirectCallsStrict {
+		return IndirectCallsDirect, nil
+	}
+	if err := isValidIndirectCallMode(mode); err != nil {
+		return "", err
+	}
+
+	return mode, nil
+}
+
+func isValidIndirectCallMode(mode IndirectCallMode) error {
+	for _, m := range IndirectCallModes {
+		if mode == m {
+			return nil
+		}
+	}
+
+	return errors.Errorf("invalid indirect call mode: %s", mode)
+}
+
+// getReferences returns the symbols referenced by instruction, other than call targets.
+func getReferences(instruction string) []string {
+	if !strings.Contains(instruction, "(SB)") || strings.Contains(instruction, "CALL") {
+		return nil
+	}
+
+	var refs []string
+	for _, match := range reference.FindAllStringSubmatch(instruction, -1) {
+		refs = append(refs, match[1])
+	}
+
+	return refs
+}
+
+// linkReferences adds calls from the symbols in references into the functions they reference,
+// as they may call them indirectly. References to itabs are linked to the methods of their
+// concrete type, whilst references to symbols that are not in the graph, such as variables, are ignored.
+func (g *callGraph) linkReferences(references map[int32][]string) {
+	var methods map[string][]int32
+	for caller, refs := range references {
+		targets := make([]int32, 0, len(refs))
+		for _, ref := range refs {
+			if typ, isItab := itabType(ref); isItab {
+				if methods == nil {
+					methods = g.methodsByType()
+				}
+				targets = append(targets, methods[typ]...)
+				continue
+			}
+
+			if target, exists := g.lookup(strings.TrimSuffix(ref, "·f")); exists {
+				targets = append(targets, target)
+			}
+		}
+
+		for _, target := range targets {
+			if !containsIndex(g.calls[caller], target) {
+				g.calls[caller] = append(g.calls[caller], target)
+			}
+		}
+	}
+}
+
+// methodsByType indexes the methods in the graph by their receiver type, without the
+// pointer indirection, as both value and pointer receivers are part of the method set.
+func (g *callGraph) methodsByType() map[string][]int32 {
+	methods := make(map[string][]int32)
+	for i, name := range g.names {
+		pkg := PackageOf(name)
+		if pkg == "" {
+			continue
+		}
+
+		parts := strings.Split(name[len(pkg)+1:], ".")
+		if len(parts) != 2 {
+			continue
+		}
+
+		typ := pkg + "." + strings.TrimSuffix(strings.TrimPrefix(parts[0], "(*"), ")")
+		methods[typ] = append(methods[typ], int32(i))
+	}
+
+	return methods
+}
+
+// itabType returns the concrete type of the itab symbol, e.g. "os.File" for "go:itab.*os.File,io.Writer".
+func itabType(symbol string) (string, bool) {
+	for _, prefix := range itabPrefixes {
+		if !strings.HasPrefix(symbol, prefix) {
+			continue
+		}
+
+		typ := symbol[len(prefix):]
+		depth := 0
+		for i, c := range typ {
+			switch c {
+			case '[':
+				depth++
+			case ']':
+				depth--
+			case ',':
+				if depth == 0 {
+					return strings.TrimPrefix(typ[:i], "*"), true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
+
+func containsIndex(indexes []int32, index int32) bool {
+	for _, i := range indexes {
+		if i == index {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getAddressTakenFunctions returns the functions in graph whose address is stored
+// in the data of source, or none when source does not implement AddressTakenReader.
+func getAddressTakenFunctions(source SourceReader, graph *callGraph) ([]string, error) {
+	r, ok := source.(AddressTakenReader)
+	if !ok {
+		return nil, nil
+	}
+
+	functions, err := r.AddressTakenFunctions()
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]string, 0, len(functions))
+	for _, fn := range functions {
+		if _, exists := graph.lookup(fn); exists {
+			found = append(found, fn)
+		}
+	}
+
+	return found, nil
+}
+
+// appendIndirectSyscalls adds the system calls reachable from the address-taken functions,
+// which were not found yet. The packages of all syscalls are attributed again, as more
+// functions may issue them.
+func appendIndirectSyscalls(ctx context.Context, graph *callGraph, syscalls []SystemCall,
+	entryPoints, addressTaken []string, table map[uint16]string) ([]SystemCall, error) {
+	unique := make(map[uint16]bool)
+	for _, s := range syscalls {
+		unique[s.ID] = true
+	}
+
+	found := dumpWalker(ctx, graph, addressTaken...)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, f := range found {
+		if !unique[f.id] {
+			unique[f.id] = true
+			syscalls = append(syscalls, SystemCall{ID: f.id, Name: table[f.id], CallPath: f.path})
+		}
+	}
+
+	attributePackages(graph, append(entryPoints[:len(entryPoints):len(entryPoints)], addressTaken...), syscalls)
+	return syscalls, nil
+}
+
+// getElfAddressTakenFunctions returns the functions whose entry address is stored
+// in the data sections of the executable, such as in itabs and function values.
+func getElfAddressTakenFunctions(filePath string) ([]string, error) {
+	f, err := elf.Open(filePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open elf file")
+	}
+	defer f.Close()
+
+	d, err := loadFunctions(f)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[uint64]string, len(d.funcs))
+	for _, fn := range d.funcs {
+		entries[fn.addr] = fn.name
+	}
+
+	ptrSize := 8
+	if f.Class == elf.ELFCLASS32 {
+		ptrSize = 4
+	}
+
+	found := make(map[string]bool)
+	for _, section := range f.Sections {
+		if !isDataSection(section) {
+			continue
+		}
+
+		data, err := section.Data()
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not read %s section", section.Name)
+		}
+
+		for off := 0; off+ptrSize <= len(data); off += ptrSize {
+			addr := uint64(f.ByteOrder.Uint32(data[off:]))
+			if ptrSize == 8 {
+				addr = f.ByteOrder.Uint64(data[off:])
+			}
+
+			if name, exists := entries[addr]; exists {
+				found[name] = true
+			}
+		}
+	}
+
+	return sortedKeys(found), nil
+}
+
+// isDataSection excludes .gopclntab, as its function table
+// points to every function in older go releases.
+func isDataSection(s *elf.Section) bool {
+	return s.Type == elf.SHT_PROGBITS && s.Flags&elf.SHF_ALLOC != 0 &&
+		s.Flags&elf.SHF_EXECINSTR == 0 && s.Name != ".gopclntab"
+}
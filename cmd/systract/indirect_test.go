@@ -0,0 +1,161 @@
+package systract
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestGetReferences(t *testing.T) {
+	assertThat := func(assumption, instruction string, expected []string) {
+		should := should.New(t)
+
+		actual := getReferences(instruction)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should get function values", "LEAQ main.main.func1·f(SB), DX", []string{"main.main.func1·f"})
+	assertThat("should get functions", "LEAQ runtime.main.func2(SB), AX", []string{"runtime.main.func2"})
+	assertThat("should get itabs", "LEAQ go.itab.*os.File,io.Writer(SB), AX", []string{"go.itab.*os.File,io.Writer"})
+	assertThat("should remove offsets", "MOVQ os.Args+8(SB), CX", []string{"os.Args"})
+	assertThat("should get immediate addresses", "MOVD $main.handler(SB), R0", []string{"main.handler"})
+	assertThat("should get tail calls", "JMP main.main(SB)", []string{"main.main"})
+	assertThat("should ignore calls", "CALL main.run(SB)", nil)
+	assertThat("should ignore relative addresses", "LEAQ 0xb3eae(IP), CX", nil)
+}
+
+func TestItabType(t *testing.T) {
+	assertThat := func(assumption, symbol, expected string, expectedFound bool) {
+		should := should.New(t)
+
+		actual, found := itabType(symbol)
+
+		should.BeEqual(expected, actual, assumption)
+		should.BeEqual(expectedFound, found, assumption)
+	}
+
+	assertThat("should get pointer types", "go.itab.*os.File,io.Writer", "os.File", true)
+	assertThat("should get value types", "go:itab.syscall.Errno,error", "syscall.Errno", true)
+	assertThat("should get generic types", "go:itab.*main.Set[int,string],main.Lener", "main.Set[int,string]", true)
+	assertThat("should ignore other symbols", "main.main", "", false)
+}
+
+func TestDumpParser_References(t *testing.T) {
+	should := should.New(t)
+	dump := `TEXT main.main(SB) main.go
+  main.go:1	0x1	488d15	LEAQ main.main.func1·f(SB), DX
+  main.go:2	0x2	488d05	LEAQ go.itab.main.pider,io.Writer(SB), AX
+  main.go:3	0x3	488b0d	MOVQ os.Args(SB), CX
+  main.go:4	0x4	e80000	CALL main.run(SB)
+
+TEXT main.main.func1(SB) main.go
+  main.go:5	0x5	b866000000	MOVL $0x66, AX
+  main.go:5	0x6	0f05	SYSCALL
+
+TEXT main.pider.Write(SB) main.go
+  main.go:7	0x7	e80000	CALL syscall.Getpid(SB)
+
+TEXT main.(*pider).Write(SB) <autogenerated>
+  main.go:8	0x8	e80000	CALL main.pider.Write(SB)
+
+`
+	parser := newAmd64Parser(2)
+	parser.references = true
+	graph, err := parser.parse(context.Background(), strings.NewReader(dump))
+
+	should.NotError(err, "should parse dump")
+	should.BeEqual(symbolDefinition{name: "main.main",
+		subCalls: []string{"main.run", "main.main.func1", "main.pider.Write", "main.(*pider).Write"}},
+		graph.symbol("main.main"), "should link functions whose address is taken and methods of itabs")
+
+	strict, err := newAmd64Parser(2).parse(context.Background(), strings.NewReader(dump))
+
+	should.NotError(err, "should parse dump")
+	should.BeEqual(symbolDefinition{name: "main.main", subCalls: []string{"main.run"}},
+		strict.symbol("main.main"), "should only link calls in strict mode")
+}
+
+func TestAppendIndirectSyscalls(t *testing.T) {
+	should := should.New(t)
+	graph := graphOf(map[string]symbolDefinition{
+		"main.main":   {subCalls: []string{"os.Write"}},
+		"os.Write":    {syscallIDs: []uint16{1}},
+		"main.hook":   {subCalls: []string{"os.Write", "os.Getuid"}},
+		"os.Getuid":   {syscallIDs: []uint16{102}},
+		"main.unused": {syscallIDs: []uint16{101}},
+	})
+	syscalls, err := extractSyscalls(context.Background(), graph, []string{"main.main"}, amd64SystemCalls)
+	should.NotError(err, "should extract syscalls")
+
+	actual, err := appendIndirectSyscalls(context.Background(), graph, syscalls,
+		[]string{"main.main"}, []string{"main.hook"}, amd64SystemCalls)
+
+	should.NotError(err, "should extract indirect syscalls")
+
+	should.BeEqual([]SystemCall{
+		{ID: 1, Name: "write", CallPath: []string{"main.main", "os.Write"}, Packages: []string{"os"}},
+		{ID: 102, Name: "getuid", CallPath: []string{"main.hook", "os.Getuid"}, Packages: []string{"os"}},
+	}, actual, "should add syscalls reachable from address-taken functions")
+}
+
+func TestGetElfAddressTakenFunctions(t *testing.T) {
+	should := should.New(t)
+
+	actual, err := getElfAddressTakenFunctions("../../test/simple-app")
+
+	should.NotError(err, "should read address-taken functions")
+	should.BeEqual(true, containsString(actual, "runtime.main"), "should find function values")
+	should.BeEqual(true, containsString(actual, "os.(*File).Write"), "should find methods in itabs")
+	should.BeEqual(false, containsString(actual, "os.(*File).write"), "should not find functions only called directly")
+}
+
+func TestExtract_IndirectCalls(t *testing.T) {
+	assertThat := func(assumption string, opts []Option, expectedErr string) {
+		should := should.New(t)
+
+		_, err := Extract(NewDumpReader("../../test/systrac.dump"), opts...)
+
+		if expectedErr == "" {
+			should.NotError(err, assumption)
+			return
+		}
+		should.BeEqual(expectedErr, err.Error(), assumption)
+	}
+
+	assertThat("should default to conservative mode", nil, "")
+	assertThat("should support direct mode", []Option{WithIndirectCalls(IndirectCallsDirect)}, "")
+	assertThat("should support deprecated strict mode", []Option{WithIndirectCalls(IndirectCallsStrict)}, "")
+	assertThat("should error for invalid modes", []Option{WithIndirectCalls("loose")}, "invalid indirect call mode: loose")
+}
+
+func TestExtract_IndirectCallsStrict(t *testing.T) {
+	should := should.New(t)
+	source := NewDumpReader("../../test/systrac.dump")
+
+	direct, err := Extract(source, WithIndirectCalls(IndirectCallsDirect), WithSort(SortByID))
+	should.NotError(err, "should extract syscalls in direct mode")
+	strict, err := Extract(source, WithIndirectCalls(IndirectCallsStrict), WithSort(SortByID))
+	should.NotError(err, "should extract syscalls in strict mode")
+
+	should.BeEqual(direct, strict, "should handle strict mode as direct")
+}
+
+func TestParseIndirectCallMode(t *testing.T) {
+	assertThat := func(assumption, value string, expected IndirectCallMode, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := ParseIndirectCallMode(value)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should parse conservative mode", "conservative", IndirectCallsConservative, false)
+	assertThat("should parse direct mode", "direct", IndirectCallsDirect, false)
+	assertThat("should resolve strict mode into direct", "strict", IndirectCallsDirect, false)
+	assertThat("should error for unknown modes", "loose", IndirectCallMode(""), true)
+}
@@ -0,0 +1,302 @@
+package systract
+
+import (
+	"debug/elf"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// ImportedSymbolsReader defines the interface for sources that can list the
+// symbols imported from shared libraries by the application they read.
+type ImportedSymbolsReader interface {
+	ImportedSymbols() ([]string, error)
+}
+
+// LinkerStubsReader defines the interface for sources that can list where the stubs
+// the linker generates to call into shared libraries are, such as the .plt section.
+type LinkerStubsReader interface {
+	LinkerStubs() ([]AddressRange, error)
+}
+
+// AddressRange represents the addresses from Start up to, but excluding, End.
+type AddressRange struct {
+	Start uint64
+	End   uint64
+}
+
+// linkerStubSections are the sections holding the stubs that call into shared libraries.
+var linkerStubSections = []string{".plt", ".plt.sec", ".plt.got"}
+
+var (
+	fileSyscalls    = []string{"open", "openat", "read", "close", "fstat", "newfstatat", "statx", "lseek"}
+	memorySyscalls  = []string{"brk", "mmap", "munmap", "mremap", "mprotect", "madvise"}
+	networkSyscalls = []string{"socket", "connect", "bind", "getsockname", "sendto", "recvfrom",
+		"sendmmsg", "recvmsg", "poll", "ioctl", "close"}
+	resolverSyscalls = concatSyscalls(fileSyscalls, networkSyscalls, memorySyscalls, []string{"futex", "getpid"})
+	userDBSyscalls   = concatSyscalls(fileSyscalls, memorySyscalls, []string{"socket", "connect", "futex"})
+	threadSyscalls   = concatSyscalls(memorySyscalls, []string{"clone", "clone3", "rt_sigprocmask",
+		"set_robust_list", "rseq", "futex"})
+	stdioSyscalls = []string{"write", "fstat", "newfstatat"}
+)
+
+// libcSyscalls maps well-known libc functions into the system calls they may make.
+var libcSyscalls = map[string][]string{
+	"open":                   {"open", "openat"},
+	"open64":                 {"open", "openat"},
+	"openat":                 {"openat"},
+	"openat64":               {"openat"},
+	"creat":                  {"creat", "openat"},
+	"read":                   {"read"},
+	"pread64":                {"pread64"},
+	"write":                  {"write"},
+	"pwrite64":               {"pwrite64"},
+	"close":                  {"close"},
+	"lseek":                  {"lseek"},
+	"lseek64":                {"lseek"},
+	"fopen":                  fileSyscalls,
+	"fopen64":                fileSyscalls,
+	"fdopen":                 {"fcntl", "fstat"},
+	"fread":                  {"read"},
+	"fwrite":                 stdioSyscalls,
+	"fclose":                 {"close", "munmap"},
+	"fflush":                 {"write"},
+	"printf":                 stdioSyscalls,
+	"fprintf":                stdioSyscalls,
+	"vfprintf":               stdioSyscalls,
+	"puts":                   stdioSyscalls,
+	"fputs":                  stdioSyscalls,
+	"stat":                   {"stat", "newfstatat", "statx"},
+	"stat64":                 {"stat", "newfstatat", "statx"},
+	"fstat":                  {"fstat", "newfstatat", "statx"},
+	"fstat64":                {"fstat", "newfstatat", "statx"},
+	"lstat":                  {"lstat", "newfstatat", "statx"},
+	"lstat64":                {"lstat", "newfstatat", "statx"},
+	"opendir":                {"openat", "fstat", "newfstatat"},
+	"readdir":                {"getdents64"},
+	"readdir64":              {"getdents64"},
+	"closedir":               {"close"},
+	"mkdir":                  {"mkdir", "mkdirat"},
+	"rmdir":                  {"rmdir", "unlinkat"},
+	"unlink":                 {"unlink", "unlinkat"},
+	"rename":                 {"rename", "renameat", "renameat2"},
+	"chmod":                  {"chmod", "fchmodat"},
+	"chown":                  {"chown", "fchownat"},
+	"chdir":                  {"chdir"},
+	"getcwd":                 {"getcwd"},
+	"chroot":                 {"chroot"},
+	"dup":                    {"dup"},
+	"dup2":                   {"dup2", "dup3"},
+	"pipe":                   {"pipe", "pipe2"},
+	"pipe2":                  {"pipe2"},
+	"fcntl":                  {"fcntl"},
+	"fcntl64":                {"fcntl"},
+	"ioctl":                  {"ioctl"},
+	"poll":                   {"poll", "ppoll"},
+	"select":                 {"select", "pselect6"},
+	"epoll_create1":          {"epoll_create1"},
+	"epoll_ctl":              {"epoll_ctl"},
+	"epoll_wait":             {"epoll_wait", "epoll_pwait"},
+	"malloc":                 memorySyscalls,
+	"calloc":                 memorySyscalls,
+	"realloc":                memorySyscalls,
+	"free":                   {"munmap", "madvise", "brk"},
+	"posix_memalign":         memorySyscalls,
+	"mmap":                   {"mmap"},
+	"mmap64":                 {"mmap"},
+	"munmap":                 {"munmap"},
+	"mprotect":               {"mprotect"},
+	"dlopen":                 concatSyscalls(fileSyscalls, memorySyscalls),
+	"socket":                 {"socket"},
+	"connect":                {"connect"},
+	"bind":                   {"bind"},
+	"listen":                 {"listen"},
+	"accept":                 {"accept", "accept4"},
+	"accept4":                {"accept4"},
+	"setsockopt":             {"setsockopt"},
+	"getsockopt":             {"getsockopt"},
+	"send":                   {"sendto"},
+	"sendto":                 {"sendto"},
+	"sendmsg":                {"sendmsg"},
+	"recv":                   {"recvfrom"},
+	"recvfrom":               {"recvfrom"},
+	"recvmsg":                {"recvmsg"},
+	"shutdown":               {"shutdown"},
+	"getaddrinfo":            resolverSyscalls,
+	"getnameinfo":            resolverSyscalls,
+	"gethostbyname":          resolverSyscalls,
+	"gethostbyname_r":        resolverSyscalls,
+	"gethostbyaddr":          resolverSyscalls,
+	"res_search":             resolverSyscalls,
+	"getpwnam":               userDBSyscalls,
+	"getpwnam_r":             userDBSyscalls,
+	"getpwuid":               userDBSyscalls,
+	"getpwuid_r":             userDBSyscalls,
+	"getgrnam":               userDBSyscalls,
+	"getgrnam_r":             userDBSyscalls,
+	"getgrgid":               userDBSyscalls,
+	"getgrgid_r":             userDBSyscalls,
+	"getgrouplist":           userDBSyscalls,
+	"pthread_create":         threadSyscalls,
+	"pthread_detach":         {"munmap"},
+	"pthread_join":           {"futex"},
+	"pthread_kill":           {"tgkill", "getpid"},
+	"pthread_sigmask":        {"rt_sigprocmask"},
+	"sigprocmask":            {"rt_sigprocmask"},
+	"sigaction":              {"rt_sigaction"},
+	"sigaltstack":            {"sigaltstack"},
+	"pthread_mutex_lock":     {"futex"},
+	"pthread_mutex_unlock":   {"futex"},
+	"pthread_cond_wait":      {"futex"},
+	"pthread_cond_signal":    {"futex"},
+	"pthread_cond_broadcast": {"futex"},
+	"nanosleep":              {"nanosleep", "clock_nanosleep"},
+	"usleep":                 {"nanosleep", "clock_nanosleep"},
+	"sleep":                  {"nanosleep", "clock_nanosleep"},
+	"clock_gettime":          {"clock_gettime"},
+	"gettimeofday":           {"gettimeofday"},
+	"time":                   {"time", "clock_gettime"},
+	"getpid":                 {"getpid"},
+	"getppid":                {"getppid"},
+	"gettid":                 {"gettid"},
+	"getuid":                 {"getuid"},
+	"geteuid":                {"geteuid"},
+	"getgid":                 {"getgid"},
+	"getegid":                {"getegid"},
+	"setuid":                 {"setuid", "tgkill", "rt_sigqueueinfo"},
+	"setgid":                 {"setgid", "tgkill", "rt_sigqueueinfo"},
+	"setgroups":              {"setgroups", "tgkill", "rt_sigqueueinfo"},
+	"uname":                  {"uname"},
+	"getrlimit":              {"getrlimit", "prlimit64"},
+	"setrlimit":              {"setrlimit", "prlimit64"},
+	"sysconf":                {"openat", "read", "close", "sched_getaffinity", "prlimit64"},
+	"getrandom":              {"getrandom"},
+	"getentropy":             {"getrandom"},
+	"fork":                   {"clone", "clone3"},
+	"vfork":                  {"vfork", "clone"},
+	"execve":                 {"execve"},
+	"execvp":                 {"execve"},
+	"waitpid":                {"wait4"},
+	"kill":                   {"kill"},
+	"raise":                  {"tgkill", "gettid", "getpid", "rt_sigprocmask"},
+	"abort":                  {"tgkill", "gettid", "getpid", "rt_sigprocmask", "rt_sigaction"},
+	"exit":                   {"exit_group"},
+	"_exit":                  {"exit_group"},
+	"syslog":                 {"socket", "connect", "sendto", "close"},
+	"prctl":                  {"prctl"},
+	"mount":                  {"mount"},
+	"umount2":                {"umount2"},
+}
+
+// getElfImportedSymbols returns the names of the symbols
+// imported from shared libraries through .dynsym.
+func getElfImportedSymbols(filePath string) ([]string, error) {
+	f, err := elf.Open(filePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open elf file")
+	}
+	defer f.Close()
+
+	imported, err := f.ImportedSymbols()
+	if err != nil {
+		if err == elf.ErrNoSymbols {
+			return []string{}, nil
+		}
+		return nil, errors.Wrap(err, "could not read dynamic symbols")
+	}
+
+	names := make([]string, 0, len(imported))
+	for _, s := range imported {
+		names = append(names, s.Name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// getElfLinkerStubs returns the address ranges of the linker stub sections of the executable.
+func getElfLinkerStubs(filePath string) ([]AddressRange, error) {
+	f, err := elf.Open(filePath)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open elf file")
+	}
+	defer f.Close()
+
+	stubs := make([]AddressRange, 0, len(linkerStubSections))
+	for _, name := range linkerStubSections {
+		if s := f.Section(name); s != nil && s.Size > 0 {
+			stubs = append(stubs, AddressRange{Start: s.Addr, End: s.Addr + s.Size})
+		}
+	}
+
+	return stubs, nil
+}
+
+// getLinkerStubs returns the linker stubs of source, or none when
+// source does not implement LinkerStubsReader.
+func getLinkerStubs(source SourceReader) ([]AddressRange, error) {
+	r, ok := source.(LinkerStubsReader)
+	if !ok {
+		return nil, nil
+	}
+
+	return r.LinkerStubs()
+}
+
+// inAnyRange checks whether address is within any of ranges.
+func inAnyRange(ranges []AddressRange, address uint64) bool {
+	for _, r := range ranges {
+		if address >= r.Start && address < r.End {
+			return true
+		}
+	}
+
+	return false
+}
+
+// appendCgoSyscalls adds the system calls made by the libc functions imported by source.
+// System calls already found in the go code are kept with their original call path.
+func appendCgoSyscalls(source SourceReader, syscalls []SystemCall, table map[uint16]string) ([]SystemCall, error) {
+	r, ok := source.(ImportedSymbolsReader)
+	if !ok {
+		return syscalls, nil
+	}
+
+	imported, err := r.ImportedSymbols()
+	if err != nil {
+		return nil, err
+	}
+
+	unique := make(map[uint16]bool)
+	for _, s := range syscalls {
+		unique[s.ID] = true
+	}
+
+	for _, function := range imported {
+		for _, name := range libcSyscalls[function] {
+			id, found := lookupSyscallID(table, name)
+			if !found || unique[id] {
+				continue
+			}
+
+			unique[id] = true
+			syscalls = append(syscalls, SystemCall{
+				ID:       id,
+				Name:     name,
+				CallPath: []string{libcPackage + "." + function},
+				Packages: []string{libcPackage},
+			})
+		}
+	}
+
+	return syscalls, nil
+}
+
+func concatSyscalls(lists ...[]string) []string {
+	all := make([]string, 0)
+	for _, l := range lists {
+		all = append(all, l...)
+	}
+
+	return all
+}
@@ -0,0 +1,161 @@
+package systract
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+type importedSymbolsStub struct {
+	*DumpReader
+	symbols []string
+	err     error
+}
+
+func (s importedSymbolsStub) ImportedSymbols() ([]string, error) {
+	return s.symbols, s.err
+}
+
+type linkerStubsStub struct {
+	*DumpReader
+	stubs []AddressRange
+}
+
+func (s linkerStubsStub) LinkerStubs() ([]AddressRange, error) {
+	return s.stubs, nil
+}
+
+func TestAppendCgoSyscalls(t *testing.T) {
+	assertThat := func(assumption string, source SourceReader, syscalls, expected []SystemCall, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := appendCgoSyscalls(source, syscalls, amd64SystemCalls)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	goSyscalls := []SystemCall{{ID: 1, Name: "write", CallPath: []string{"main.main", "syscall.write"}}}
+	dump := NewDumpReader("../../test/single-syscall.dump")
+
+	assertThat("should ignore sources without imported symbols", dump, goSyscalls, goSyscalls, false)
+	assertThat("should ignore functions not in the libc database",
+		importedSymbolsStub{dump, []string{"strlen", "__errno_location"}, nil}, goSyscalls, goSyscalls, false)
+	assertThat("should map libc functions into syscalls",
+		importedSymbolsStub{dump, []string{"close", "open64"}, nil}, []SystemCall{},
+		[]SystemCall{
+			{ID: 3, Name: "close", CallPath: []string{"libc.close"}, Packages: []string{"libc"}},
+			{ID: 2, Name: "open", CallPath: []string{"libc.open64"}, Packages: []string{"libc"}},
+			{ID: 257, Name: "openat", CallPath: []string{"libc.open64"}, Packages: []string{"libc"}},
+		}, false)
+	assertThat("should keep call path of syscalls found in go code",
+		importedSymbolsStub{dump, []string{"fputs"}, nil}, goSyscalls,
+		[]SystemCall{
+			{ID: 1, Name: "write", CallPath: []string{"main.main", "syscall.write"}},
+			{ID: 5, Name: "fstat", CallPath: []string{"libc.fputs"}, Packages: []string{"libc"}},
+			{ID: 262, Name: "newfstatat", CallPath: []string{"libc.fputs"}, Packages: []string{"libc"}},
+		}, false)
+	assertThat("should error when imported symbols cannot be read",
+		importedSymbolsStub{dump, nil, errors.New("stub")}, goSyscalls, nil, true)
+}
+
+func TestGetElfImportedSymbols(t *testing.T) {
+	assertThat := func(assumption, filePath string, expected []string, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := getElfImportedSymbols(filePath)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should return no symbols for static executables", "../../test/simple-app", []string{}, false)
+	assertThat("should error when file is not an executable", "../../test/simple-app.go", []string(nil), true)
+}
+
+func TestGetElfLinkerStubs(t *testing.T) {
+	assertThat := func(assumption, filePath string, expected []AddressRange, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := getElfLinkerStubs(filePath)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should return no stubs for static executables", "../../test/simple-app", []AddressRange{}, false)
+	assertThat("should error when file is not an executable", "../../test/simple-app.go", []AddressRange(nil), true)
+}
+
+func TestExtract_E2E_CgoLinkerStubs(t *testing.T) {
+	should := should.New(t)
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("gcc is required to build cgo executables")
+	}
+
+	dir, err := ioutil.TempDir("", "gosystract-test")
+	if err != nil {
+		t.Fatalf("could not setup test properly, got error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := filepath.Join(dir, "main.go")
+	code := "package main\n\n// #include <unistd.h>\n// static int pid() { return getpid(); }\nimport \"C\"\n\n" +
+		"func main() { println(C.pid()) }\n"
+	if err := ioutil.WriteFile(src, []byte(code), 0600); err != nil {
+		t.Fatalf("could not setup test properly, got error: %s", err)
+	}
+	exePath := filepath.Join(dir, "cgo-app")
+	build := exec.Command("go", "build", "-o", exePath, src)
+	build.Env = append(os.Environ(), "CGO_ENABLED=1")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("could not build test executable: %s: %s", err, out)
+	}
+
+	// objdump is used as it also disassembles the C functions, which call into .plt.
+	dumpPath := filepath.Join(dir, "cgo-app.dump")
+	dump, err := exec.Command("go", "tool", "objdump", exePath).Output()
+	if err != nil {
+		t.Fatalf("could not disassemble test executable: %s", err)
+	}
+	if err := ioutil.WriteFile(dumpPath, dump, 0600); err != nil {
+		t.Fatalf("could not setup test properly, got error: %s", err)
+	}
+
+	stubs, err := NewExeReader(exePath).LinkerStubs()
+	should.NotError(err, "should read linker stubs")
+	should.BeTrue(len(stubs) > 0, "should find the .plt of cgo executables")
+
+	opts := []Option{WithArchitecture("amd64"), WithEntryPoints("main.main", ".*_Cfunc_pid")}
+	withoutStubs, err := NewExtractor(opts...).Extract(context.Background(), NewDumpReader(dumpPath))
+	should.NotError(err, "should extract syscalls without stubs")
+	withStubs, err := NewExtractor(opts...).Extract(context.Background(),
+		linkerStubsStub{DumpReader: NewDumpReader(dumpPath), stubs: stubs})
+	should.NotError(err, "should extract syscalls with stubs")
+
+	should.BeTrue(countWarnings(withoutStubs.Warnings, WarningUnresolvedCall) > 0,
+		"should not resolve calls into .plt without stubs")
+	should.BeEqual(0, countWarnings(withStubs.Warnings, WarningUnresolvedCall),
+		"should ignore calls into .plt")
+}
+
+// countWarnings returns how many warnings are of kind.
+func countWarnings(warnings []Warning, kind WarningKind) int {
+	n := 0
+	for _, w := range warnings {
+		if w.Kind == kind {
+			n++
+		}
+	}
+
+	return n
+}
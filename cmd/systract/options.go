@@ -0,0 +1,153 @@
+package systract
+
+import "runtime"
+
+// Option defines a configuration option for the extraction of system calls.
+type Option func(*options)
+
+type options struct {
+	arch          string
+	goVersion     string
+	cgo           bool
+	progress      func(Progress)
+	jobs          int
+	sort          SortOrder
+	entryPoints   []string
+	runtimeRoots  bool
+	indirectCalls IndirectCallMode
+	catalog       SyscallCatalog
+	logger        Logger
+	strict        bool
+}
+
+// Logger defines the interface for loggers, which is satisfied by *log.Logger.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// Progress represents how far the parsing of a dump has gone.
+type Progress struct {
+	// Lines is the number of dump lines parsed.
+	Lines int
+	// Symbols is the number of functions parsed.
+	Symbols int
+	// Done defines whether the whole dump was parsed.
+	Done bool
+}
+
+// WithArchitecture overrides the architecture detected from the source,
+// defining which syscall table is used to resolve the system call IDs.
+func WithArchitecture(arch string) Option {
+	return func(o *options) {
+		o.arch = arch
+	}
+}
+
+// WithGoVersion defines the go release the application was built with
+// (e.g. "go1.19"), restricting the catalog to the syscall wrappers
+// that exist in that release.
+func WithGoVersion(version string) Option {
+	return func(o *options) {
+		o.goVersion = version
+	}
+}
+
+// WithCgo enables the resolution of system calls made through libc,
+// based on the functions the executable imports from shared libraries.
+// It has no effect on sources that do not implement ImportedSymbolsReader.
+func WithCgo() Option {
+	return func(o *options) {
+		o.cgo = true
+	}
+}
+
+// WithProgress sets a function to be called periodically whilst the dump
+// is parsed, and once more when parsing is done. As dumps of large executables
+// take a while to be parsed, it can be used to show their progress.
+func WithProgress(fn func(Progress)) Option {
+	return func(o *options) {
+		o.progress = fn
+	}
+}
+
+// WithJobs defines the number of functions parsed concurrently,
+// which defaults to the number of CPUs. The results are the same regardless of it.
+func WithJobs(jobs int) Option {
+	return func(o *options) {
+		o.jobs = jobs
+	}
+}
+
+// WithSort defines the order in which system calls are returned,
+// which defaults to SortByDiscovery.
+func WithSort(order SortOrder) Option {
+	return func(o *options) {
+		o.sort = order
+	}
+}
+
+// WithEntryPoints replaces DefaultEntryPoints with the symbols matching patterns,
+// which are either symbol names (e.g. "main.run") or regular expressions matching
+// whole symbol names (e.g. "plugin/unnamed-.*\.Handle"). It is useful for plugins,
+// test binaries and libraries, which do not start from main.main.
+// Extraction fails when any of the patterns matches no symbols.
+func WithEntryPoints(patterns ...string) Option {
+	return func(o *options) {
+		o.entryPoints = append(o.entryPoints, patterns...)
+	}
+}
+
+// WithRuntimeRoots also walks the call graph from the entry points of the go runtime,
+// such as its bootstrap, signal handlers and background workers, and includes the
+// syscalls in RuntimeBaseline for the go release of the source. The syscalls only
+// found this way are marked as Runtime.
+func WithRuntimeRoots() Option {
+	return func(o *options) {
+		o.runtimeRoots = true
+	}
+}
+
+// WithIndirectCalls defines how calls through interfaces and function values are handled.
+// It defaults to IndirectCallsConservative, which finds more system calls than the direct calls
+// previous releases followed, whilst IndirectCallsDirect keeps their results. The functions whose address is stored in the data
+// of the executable are only found for sources that implement AddressTakenReader.
+func WithIndirectCalls(mode IndirectCallMode) Option {
+	return func(o *options) {
+		if mode == IndirectCallsStrict {
+			mode = IndirectCallsDirect
+		}
+		o.indirectCalls = mode
+	}
+}
+
+// WithCatalog replaces DefaultCatalog with the syscall wrappers in catalog,
+// which is still narrowed down to the go release of the source.
+func WithCatalog(catalog SyscallCatalog) Option {
+	return func(o *options) {
+		o.catalog = catalog
+	}
+}
+
+// WithLogger sets a logger for the warnings found during extraction.
+func WithLogger(logger Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// WithStrict fails extractions that raise any warning that is not informational,
+// returning them as a WarningsError.
+func WithStrict() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{jobs: runtime.NumCPU(), indirectCalls: IndirectCallsConservative, catalog: DefaultCatalog}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
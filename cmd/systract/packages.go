@@ -0,0 +1,218 @@
+package systract
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	// StdModule groups the packages of the go standard library.
+	StdModule string = "std"
+	// libcPackage is the pseudo package the libc functions imported by cgo executables belong to.
+	libcPackage string = "libc"
+)
+
+// ModuleSyscalls represents the system calls directly issued by the packages of a module.
+type ModuleSyscalls struct {
+	// Module is the module path, StdModule for the standard library
+	// or empty when the module cannot be determined.
+	Module   string            `json:"module" yaml:"module"`
+	Version  string            `json:"version,omitempty" yaml:"version,omitempty"`
+	Packages []PackageSyscalls `json:"packages" yaml:"packages"`
+}
+
+// PackageSyscalls represents the system calls directly issued by the functions of a package.
+type PackageSyscalls struct {
+	Package  string       `json:"package" yaml:"package"`
+	Syscalls []SystemCall `json:"syscalls" yaml:"syscalls"`
+}
+
+// PackageOf returns the package of a go symbol, e.g. "golang.org/x/sys/unix"
+// for "golang.org/x/sys/unix.Mount" or "os" for "os.(*File).Write".
+// The characters the go linker escapes in symbols are unescaped, e.g. "gopkg.in/yaml.v2"
+// is returned for "gopkg.in/yaml%2ev2.Unmarshal".
+// Empty is returned for symbols that are not qualified by a package.
+func PackageOf(symbol string) string {
+	if i := strings.Index(symbol, "["); i >= 0 {
+		symbol = symbol[:i]
+	}
+
+	start := strings.LastIndex(symbol, "/") + 1
+	dot := strings.Index(symbol[start:], ".")
+	if dot <= 0 {
+		return ""
+	}
+
+	pkg := symbol[:start+dot]
+	if unescaped, err := url.PathUnescape(pkg); err == nil {
+		return unescaped
+	}
+	return pkg
+}
+
+// ModuleOf returns the path and version of the module pkg belongs to, based on the
+// main module and dependencies in info. Packages of the standard library belong to StdModule,
+// whilst empty is returned when the module cannot be determined.
+func ModuleOf(pkg string, info *BuildInfo) (module, version string) {
+	if pkg == libcPackage {
+		return libcPackage, ""
+	}
+
+	if pkg == "main" {
+		if info != nil {
+			return info.MainModule, ""
+		}
+		return "", ""
+	}
+
+	if info != nil {
+		if isWithinModule(pkg, info.MainModule) {
+			return info.MainModule, ""
+		}
+
+		for _, d := range info.Dependencies {
+			if isWithinModule(pkg, d.Path) && len(d.Path) > len(module) {
+				module, version = d.Path, d.Version
+			}
+		}
+		if module != "" {
+			return module, version
+		}
+	}
+
+	if isStdPackage(pkg) {
+		return StdModule, ""
+	}
+
+	return "", ""
+}
+
+// GroupByModule groups syscalls by the packages that directly issue them,
+// and then by the modules those packages belong to. Modules and packages are sorted by name.
+func GroupByModule(syscalls []SystemCall, info *BuildInfo) []ModuleSyscalls {
+	modules := make(map[string]*ModuleSyscalls)
+	packages := make(map[string]*PackageSyscalls)
+	moduleOf := make(map[string]string)
+
+	for _, s := range syscalls {
+		for _, pkg := range s.Packages {
+			p, exists := packages[pkg]
+			if !exists {
+				module, version := ModuleOf(pkg, info)
+				if _, exists := modules[module]; !exists {
+					modules[module] = &ModuleSyscalls{Module: module, Version: version}
+				}
+
+				p = &PackageSyscalls{Package: pkg}
+				packages[pkg] = p
+				moduleOf[pkg] = module
+			}
+
+			p.Syscalls = append(p.Syscalls, SystemCall{ID: s.ID, Name: s.Name})
+		}
+	}
+
+	pkgNames := make([]string, 0, len(packages))
+	for pkg := range packages {
+		pkgNames = append(pkgNames, pkg)
+	}
+	sort.Strings(pkgNames)
+	for _, pkg := range pkgNames {
+		m := modules[moduleOf[pkg]]
+		m.Packages = append(m.Packages, *packages[pkg])
+	}
+
+	grouped := make([]ModuleSyscalls, 0, len(modules))
+	for _, m := range modules {
+		grouped = append(grouped, *m)
+	}
+	sort.Slice(grouped, func(i, j int) bool {
+		return grouped[i].Module < grouped[j].Module
+	})
+
+	return grouped
+}
+
+// attributePackages sets the packages of each syscall, based on the symbols reachable
+// from the entry points that directly issue them.
+func attributePackages(graph *callGraph, entryPoints []string, syscalls []SystemCall) {
+	issuedBy := make(map[uint16]map[string]bool)
+	for i, reachable := range reachableSymbols(graph, entryPoints) {
+		pkg := PackageOf(graph.names[i])
+		if !reachable || pkg == "" {
+			continue
+		}
+
+		for _, id := range graph.syscalls[i] {
+			if issuedBy[id] == nil {
+				issuedBy[id] = make(map[string]bool)
+			}
+			issuedBy[id][pkg] = true
+		}
+	}
+
+	for i := range syscalls {
+		syscalls[i].Packages = sortedKeys(issuedBy[syscalls[i].ID])
+	}
+}
+
+func reachableSymbols(graph *callGraph, entryPoints []string) []bool {
+	visited := make([]bool, graph.len())
+	queue := make([]int32, 0, len(entryPoints))
+	for _, ep := range entryPoints {
+		if i, exists := graph.lookup(ep); exists {
+			queue = append(queue, i)
+		}
+	}
+
+	for len(queue) > 0 {
+		symbol := queue[0]
+		queue = queue[1:]
+
+		if visited[symbol] {
+			continue
+		}
+		visited[symbol] = true
+
+		queue = append(queue, graph.calls[symbol]...)
+	}
+
+	return visited
+}
+
+// mergePackages returns the sorted union of the packages in a and b.
+func mergePackages(a, b []string) []string {
+	unique := make(map[string]bool, len(a)+len(b))
+	for _, pkg := range append(append([]string{}, a...), b...) {
+		unique[pkg] = true
+	}
+
+	return sortedKeys(unique)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func isWithinModule(pkg, module string) bool {
+	return module != "" && (pkg == module || strings.HasPrefix(pkg, module+"/"))
+}
+
+// isStdPackage follows the go toolchain convention, in which only the
+// standard library has import paths without a dot in their first element.
+// Its vendored dependencies are prefixed with "vendor/".
+func isStdPackage(pkg string) bool {
+	first := strings.SplitN(pkg, "/", 2)[0]
+	return first == "vendor" || !strings.Contains(first, ".")
+}
@@ -0,0 +1,80 @@
+package systract
+
+import (
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestPackageOf(t *testing.T) {
+	assertThat := func(assumption, symbol, expected string) {
+		should := should.New(t)
+
+		actual := PackageOf(symbol)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should return package of functions", "syscall.Syscall", "syscall")
+	assertThat("should return package of methods", "os.(*File).Write", "os")
+	assertThat("should return package with import path", "golang.org/x/sys/unix.Mount", "golang.org/x/sys/unix")
+	assertThat("should return package with dots in its path", "github.com/x/y.v2.Func", "github.com/x/y")
+	assertThat("should unescape dots in the last element of import paths", "gopkg.in/yaml%2ev2.Unmarshal", "gopkg.in/yaml.v2")
+	assertThat("should ignore type parameters", "example.com/p.Map[go.shape.*example.com/q.T]", "example.com/p")
+	assertThat("should return empty for unqualified symbols", "_cgo_topofstack", "")
+}
+
+func TestModuleOf(t *testing.T) {
+	assertThat := func(assumption, pkg string, info *BuildInfo, expectedModule, expectedVersion string) {
+		should := should.New(t)
+
+		module, version := ModuleOf(pkg, info)
+
+		should.BeEqual(expectedModule, module, assumption)
+		should.BeEqual(expectedVersion, version, assumption)
+	}
+
+	info := &BuildInfo{
+		MainModule: "example.com/app",
+		Dependencies: []Dependency{
+			{Path: "golang.org/x/sys", Version: "v0.1.0"},
+			{Path: "example.com/lib", Version: "v1.0.0"},
+			{Path: "example.com/lib/v2", Version: "v2.3.0"},
+		},
+	}
+
+	assertThat("should return main module for main package", "main", info, "example.com/app", "")
+	assertThat("should return main module for its packages", "example.com/app/internal", info, "example.com/app", "")
+	assertThat("should return dependency with version", "golang.org/x/sys/unix", info, "golang.org/x/sys", "v0.1.0")
+	assertThat("should return longest matching dependency", "example.com/lib/v2/sub", info, "example.com/lib/v2", "v2.3.0")
+	assertThat("should return std for standard library", "internal/poll", info, StdModule, "")
+	assertThat("should return std for vendored standard library", "vendor/golang.org/x/net/route", info, StdModule, "")
+	assertThat("should return libc for cgo functions", "libc", info, "libc", "")
+	assertThat("should return empty for unknown modules", "example.org/other", info, "", "")
+	assertThat("should return std without build info", "syscall", nil, StdModule, "")
+	assertThat("should return empty for main package without build info", "main", nil, "", "")
+}
+
+func TestGroupByModule(t *testing.T) {
+	should := should.New(t)
+	info := &BuildInfo{MainModule: "example.com/app",
+		Dependencies: []Dependency{{Path: "golang.org/x/sys", Version: "v0.1.0"}}}
+	syscalls := []SystemCall{
+		{ID: 165, Name: "mount", CallPath: []string{"main.main", "golang.org/x/sys/unix.Mount"},
+			Packages: []string{"golang.org/x/sys/unix"}},
+		{ID: 1, Name: "write", Packages: []string{"syscall", "golang.org/x/sys/unix"}},
+		{ID: 0, Name: "read", Packages: []string{"syscall"}},
+		{ID: 231, Name: "exit_group"},
+	}
+
+	actual := GroupByModule(syscalls, info)
+
+	should.BeEqual([]ModuleSyscalls{
+		{Module: "golang.org/x/sys", Version: "v0.1.0", Packages: []PackageSyscalls{
+			{Package: "golang.org/x/sys/unix", Syscalls: []SystemCall{{ID: 165, Name: "mount"}, {ID: 1, Name: "write"}}},
+		}},
+		{Module: StdModule, Packages: []PackageSyscalls{
+			{Package: "syscall", Syscalls: []SystemCall{{ID: 1, Name: "write"}, {ID: 0, Name: "read"}}},
+		}},
+	}, actual, "should group syscalls by the modules of the packages that issue them")
+}
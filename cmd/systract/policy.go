@@ -0,0 +1,50 @@
+package systract
+
+// DefaultDenyList are system calls applications seldom need, which can be used to take over
+// or escape from the host, e.g. by tracing other processes or loading kernel modules.
+var DefaultDenyList = []string{
+	"ptrace", "process_vm_readv", "process_vm_writev",
+	"kexec_load", "kexec_file_load", "init_module", "finit_module", "delete_module",
+	"reboot", "swapon", "swapoff", "iopl", "ioperm", "acct", "open_by_handle_at",
+}
+
+// Policy defines the system calls an application is permitted to make.
+type Policy struct {
+	// Allowed are the only system calls permitted. When it is nil,
+	// all system calls that are not denied are permitted.
+	Allowed []string
+	// Denied are the system calls never permitted, even when allowed.
+	Denied []string
+}
+
+// PolicyViolation represents a system call that is not permitted by a policy.
+type PolicyViolation struct {
+	Syscall SystemCall
+	// Denied defines whether the system call is denied, rather than not allowed.
+	Denied bool
+}
+
+// Check returns the violations of the policy by syscalls, in the order they were provided.
+// System calls are matched by name, so policies apply to all architectures.
+func (p Policy) Check(syscalls []SystemCall) []PolicyViolation {
+	allowed := make(map[string]bool, len(p.Allowed))
+	for _, name := range p.Allowed {
+		allowed[name] = true
+	}
+	denied := make(map[string]bool, len(p.Denied))
+	for _, name := range p.Denied {
+		denied[name] = true
+	}
+
+	violations := make([]PolicyViolation, 0)
+	for _, s := range syscalls {
+		switch {
+		case denied[s.Name]:
+			violations = append(violations, PolicyViolation{Syscall: s, Denied: true})
+		case p.Allowed != nil && !allowed[s.Name]:
+			violations = append(violations, PolicyViolation{Syscall: s})
+		}
+	}
+
+	return violations
+}
@@ -0,0 +1,33 @@
+package systract
+
+import (
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestPolicy_Check(t *testing.T) {
+	assertThat := func(assumption string, policy Policy, syscalls []SystemCall, expected []PolicyViolation) {
+		should := should.New(t)
+
+		actual := policy.Check(syscalls)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	write := SystemCall{ID: 1, Name: "write", CallPath: []string{"main.main", "syscall.write"}}
+	read := SystemCall{ID: 0, Name: "read", CallPath: []string{"main.main", "syscall.read"}}
+	ptrace := SystemCall{ID: 101, Name: "ptrace", CallPath: []string{"main.main", "syscall.PtraceAttach"}}
+
+	assertThat("should permit all syscalls for empty policies", Policy{},
+		[]SystemCall{write, ptrace}, []PolicyViolation{})
+	assertThat("should report syscalls not allowed", Policy{Allowed: []string{"write"}},
+		[]SystemCall{write, read}, []PolicyViolation{{Syscall: read}})
+	assertThat("should not allow any syscall for empty allow-lists", Policy{Allowed: []string{}},
+		[]SystemCall{write}, []PolicyViolation{{Syscall: write}})
+	assertThat("should report denied syscalls even when allowed",
+		Policy{Allowed: []string{"write", "ptrace"}, Denied: DefaultDenyList},
+		[]SystemCall{write, ptrace}, []PolicyViolation{{Syscall: ptrace, Denied: true}})
+	assertThat("should match syscalls by name across architectures", Policy{Allowed: []string{"write"}},
+		[]SystemCall{{ID: 64, Name: "write"}}, []PolicyViolation{})
+}
@@ -0,0 +1,99 @@
+package systract
+
+import "context"
+
+// runtimeEntryPoints are the functions of the go runtime that run outside of the
+// call graph of main.main, such as the process bootstrap, the threads started by
+// the scheduler, the signal handlers and the background workers of the GC.
+var runtimeEntryPoints = []string{
+	"_rt0_amd64_linux", "_rt0_386_linux", "_rt0_arm64_linux", "_rt0_arm_linux",
+	"_rt0_ppc64le_linux", "_rt0_s390x_linux", "_rt0_riscv64_linux",
+	"runtime.rt0_go", "runtime.main", "runtime.mstart", "runtime.mstart0", "runtime.mstart1",
+	"runtime.newosproc", "runtime.minit", "runtime.sysmon", "runtime.templateThread",
+	"runtime.sigtramp", "runtime.cgoSigtramp", "runtime.sigtrampgo", "runtime.sighandler",
+	"runtime.sigreturn", "runtime.sigreturn__sigaction",
+	"runtime.bgsweep", "runtime.bgscavenge", "runtime.gcBgMarkWorker", "runtime.forcegchelper",
+	"runtime.runfinq", "runtime.timerproc", "runtime.morestack", "runtime.newstack",
+	"runtime.netpollinit", "runtime.netpollBreak", "runtime.fatalpanic", "runtime.raiseproc",
+}
+
+// RuntimeSyscalls represents system calls the go runtime requires in order to
+// start and run any application, which may not be found in the executable.
+type RuntimeSyscalls struct {
+	Syscalls []string
+	// Since and Until define the go releases in which the syscalls are required.
+	// Empty values mean the syscalls are not bound to a go release.
+	Since string
+	Until string
+}
+
+// RuntimeBaseline is a curated list of the system calls made by the go runtime
+// on linux. Syscalls that do not exist in an architecture are ignored.
+var RuntimeBaseline = []RuntimeSyscalls{
+	{Syscalls: []string{
+		"rt_sigaction", "rt_sigprocmask", "rt_sigreturn", "sigaltstack", "clone", "exit", "exit_group",
+		"mmap", "munmap", "madvise", "futex", "gettid", "getpid", "tgkill", "sched_yield",
+		"sched_getaffinity", "nanosleep", "clock_gettime", "arch_prctl", "openat", "read", "write", "close",
+		"epoll_create1", "epoll_ctl", "epoll_pwait", "epoll_wait",
+	}},
+	{Syscalls: []string{"pipe2"}, Since: "1.14", Until: "1.20"},
+	{Syscalls: []string{"getrlimit", "setrlimit", "prlimit64"}, Since: "1.19"},
+	{Syscalls: []string{"eventfd2", "fcntl"}, Since: "1.21"},
+}
+
+// getRuntimeEntryPoints returns the runtime entry points found in graph,
+// including their ABI0 variants.
+func getRuntimeEntryPoints(graph *callGraph) []string {
+	ep := make([]string, 0)
+	for _, name := range runtimeEntryPoints {
+		for _, symbol := range []string{name, name + ".abi0"} {
+			if _, exists := graph.lookup(symbol); exists {
+				ep = append(ep, symbol)
+			}
+		}
+	}
+
+	return ep
+}
+
+// appendRuntimeSyscalls adds the system calls reachable from the runtime entry points,
+// followed by the ones in RuntimeBaseline for goVersion, which were not found yet.
+// They are marked as Runtime, so they can be reported separately.
+func appendRuntimeSyscalls(ctx context.Context, graph *callGraph, syscalls []SystemCall,
+	table map[uint16]string, goVersion string) ([]SystemCall, error) {
+	unique := make(map[uint16]bool)
+	for _, s := range syscalls {
+		unique[s.ID] = true
+	}
+
+	found, err := extractSyscalls(ctx, graph, getRuntimeEntryPoints(graph), table)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range found {
+		if !unique[s.ID] {
+			unique[s.ID] = true
+			s.Runtime = true
+			syscalls = append(syscalls, s)
+		}
+	}
+
+	for _, baseline := range RuntimeBaseline {
+		if !isInGoVersion(goVersion, baseline.Since, baseline.Until) {
+			continue
+		}
+
+		for _, name := range baseline.Syscalls {
+			id, found := lookupSyscallID(table, name)
+			if !found || unique[id] {
+				continue
+			}
+
+			unique[id] = true
+			syscalls = append(syscalls, SystemCall{ID: id, Name: name, Runtime: true})
+		}
+	}
+
+	return syscalls, nil
+}
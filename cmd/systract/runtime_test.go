@@ -0,0 +1,66 @@
+package systract
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestAppendRuntimeSyscalls(t *testing.T) {
+	assertThat := func(assumption, goVersion string, expected []SystemCall) {
+		should := should.New(t)
+		graph := graphOf(map[string]symbolDefinition{
+			"main.main":                     {syscallIDs: []uint16{1}},
+			"runtime.rt0_go.abi0":           {subCalls: []string{"runtime.mstart"}},
+			"runtime.mstart":                {subCalls: []string{"runtime.minit"}},
+			"runtime.minit":                 {syscallIDs: []uint16{131, 1}},
+			"runtime.unreachable":           {syscallIDs: []uint16{39}},
+			"runtime/internal/syscall.init": {},
+		})
+		syscalls := []SystemCall{{ID: 1, Name: "write", CallPath: []string{"main.main"}}}
+		baseline := RuntimeBaseline
+		RuntimeBaseline = []RuntimeSyscalls{
+			{Syscalls: []string{"clone", "sigaltstack", "not_a_syscall"}},
+			{Syscalls: []string{"eventfd2"}, Since: "1.21"},
+		}
+		defer func() { RuntimeBaseline = baseline }()
+
+		actual, err := appendRuntimeSyscalls(context.Background(), graph, syscalls, amd64SystemCalls, goVersion)
+
+		should.NotError(err, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should add syscalls of runtime entry points and baseline", "go1.20",
+		[]SystemCall{
+			{ID: 1, Name: "write", CallPath: []string{"main.main"}},
+			{ID: 131, Name: "sigaltstack", CallPath: []string{"runtime.minit"},
+				Packages: []string{"runtime"}, Runtime: true},
+			{ID: 56, Name: "clone", Runtime: true},
+		})
+	assertThat("should add baseline syscalls of the go version", "go1.21.3",
+		[]SystemCall{
+			{ID: 1, Name: "write", CallPath: []string{"main.main"}},
+			{ID: 131, Name: "sigaltstack", CallPath: []string{"runtime.minit"},
+				Packages: []string{"runtime"}, Runtime: true},
+			{ID: 56, Name: "clone", Runtime: true},
+			{ID: 290, Name: "eventfd2", Runtime: true},
+		})
+}
+
+func TestExtract_RuntimeRoots(t *testing.T) {
+	should := should.New(t)
+
+	syscalls, err := Extract(NewExeReader("../../test/simple-app"), WithRuntimeRoots(),
+		WithIndirectCalls(IndirectCallsDirect), WithSort(SortByName))
+
+	should.NotError(err, "should extract syscalls with runtime roots")
+	runtime := make(map[string]bool)
+	for _, s := range syscalls {
+		runtime[s.Name] = s.Runtime
+	}
+	should.BeEqual(false, runtime["write"], "should not mark syscalls reachable from main as runtime")
+	should.BeEqual(true, runtime["sigaltstack"], "should find syscalls only made by the runtime")
+	should.BeEqual(true, runtime["rt_sigreturn"], "should include baseline syscalls")
+}
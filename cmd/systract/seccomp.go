@@ -0,0 +1,127 @@
+package systract
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// SeccompActionAllow allows the system call to be executed.
+	SeccompActionAllow string = "SCMP_ACT_ALLOW"
+	// SeccompActionErrno returns an error to the caller without executing the system call.
+	SeccompActionErrno string = "SCMP_ACT_ERRNO"
+)
+
+// seccompArchitectures maps go architecture names into their seccomp equivalent.
+var seccompArchitectures = map[string]string{
+	"amd64":   "SCMP_ARCH_X86_64",
+	"386":     "SCMP_ARCH_X86",
+	"arm64":   "SCMP_ARCH_AARCH64",
+	"arm":     "SCMP_ARCH_ARM",
+	"ppc64le": "SCMP_ARCH_PPC64LE",
+	"s390x":   "SCMP_ARCH_S390X",
+	"riscv64": "SCMP_ARCH_RISCV64",
+}
+
+// SeccompProfile represents a Docker/OCI compatible seccomp profile.
+type SeccompProfile struct {
+	DefaultAction string           `json:"defaultAction"`
+	Architectures []string         `json:"architectures"`
+	Syscalls      []SeccompSyscall `json:"syscalls"`
+}
+
+// SeccompSyscall represents a group of system calls that share the same action.
+type SeccompSyscall struct {
+	Names  []string `json:"names"`
+	Action string   `json:"action"`
+}
+
+// NewSeccompProfile creates a seccomp profile which only allows the syscalls provided,
+// alongside execve and the syscalls in RuntimeBaseline of all go releases, without which
+// the container could neither start the executable nor have the go runtime bootstrap it.
+// Any other system call made on the given architectures will be denied with an error.
+func NewSeccompProfile(syscalls []SystemCall, archs ...string) (*SeccompProfile, error) {
+	if len(archs) == 0 {
+		return nil, errors.New("no architecture provided")
+	}
+
+	seccompArchs := make([]string, 0, len(archs))
+	tables := make([]map[uint16]string, 0, len(archs))
+	for _, arch := range archs {
+		seccompArch, exists := seccompArchitectures[arch]
+		if !exists {
+			return nil, errors.Wrap(ErrUnsupportedArch, arch)
+		}
+		if !containsString(seccompArchs, seccompArch) {
+			seccompArchs = append(seccompArchs, seccompArch)
+			tables = append(tables, syscallTables[arch])
+		}
+	}
+
+	names := make([]string, 0, len(syscalls))
+	unique := make(map[string]bool)
+	for _, syscall := range syscalls {
+		if _, exists := unique[syscall.Name]; !exists && syscall.Name != "" {
+			unique[syscall.Name] = true
+			names = append(names, syscall.Name)
+		}
+	}
+	for _, name := range seccompBaseline() {
+		if !unique[name] && existsInAny(tables, name) {
+			unique[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	return &SeccompProfile{
+		DefaultAction: SeccompActionErrno,
+		Architectures: seccompArchs,
+		Syscalls: []SeccompSyscall{
+			{Names: names, Action: SeccompActionAllow},
+		},
+	}, nil
+}
+
+// Write serialises the profile as indented JSON into output.
+func (p *SeccompProfile) Write(output io.Writer) error {
+	encoder := json.NewEncoder(output)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(p)
+}
+
+// seccompBaseline returns the syscalls every profile allows: execve, which runs the
+// executable, and the ones in RuntimeBaseline regardless of the go release.
+func seccompBaseline() []string {
+	names := []string{"execve"}
+	for _, baseline := range RuntimeBaseline {
+		names = append(names, baseline.Syscalls...)
+	}
+
+	return names
+}
+
+// existsInAny checks whether name is a syscall of at least one of tables.
+func existsInAny(tables []map[uint16]string, name string) bool {
+	for _, table := range tables {
+		if _, found := lookupSyscallID(table, name); found {
+			return true
+		}
+	}
+
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,148 @@
+package systract
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+// amd64Baseline and arm64Baseline are the syscalls allowed by all profiles of the architecture.
+var (
+	amd64Baseline = []string{
+		"arch_prctl", "clock_gettime", "clone", "close", "epoll_create1", "epoll_ctl", "epoll_pwait",
+		"epoll_wait", "eventfd2", "execve", "exit", "exit_group", "fcntl", "futex", "getpid", "getrlimit",
+		"gettid", "madvise", "mmap", "munmap", "nanosleep", "openat", "pipe2", "prlimit64", "read",
+		"rt_sigaction", "rt_sigprocmask", "rt_sigreturn", "sched_getaffinity", "sched_yield", "setrlimit",
+		"sigaltstack", "tgkill", "write",
+	}
+	arm64Baseline = []string{
+		"clock_gettime", "clone", "close", "epoll_create1", "epoll_ctl", "epoll_pwait", "eventfd2",
+		"execve", "exit", "exit_group", "fcntl", "futex", "getpid", "getrlimit", "gettid", "madvise",
+		"mmap", "munmap", "nanosleep", "openat", "pipe2", "prlimit64", "read", "rt_sigaction",
+		"rt_sigprocmask", "rt_sigreturn", "sched_getaffinity", "sched_yield", "setrlimit", "sigaltstack",
+		"tgkill", "write",
+	}
+)
+
+func TestNewSeccompProfile(t *testing.T) {
+	assertThat := func(assumption string, syscalls []SystemCall, arch string,
+		expected *SeccompProfile, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := NewSeccompProfile(syscalls, arch)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should allow syscalls sorted by name",
+		[]SystemCall{{ID: 79, Name: "getcwd"}, {ID: 4, Name: "stat"}}, "amd64",
+		&SeccompProfile{
+			DefaultAction: SeccompActionErrno,
+			Architectures: []string{"SCMP_ARCH_X86_64"},
+			Syscalls: []SeccompSyscall{
+				{Names: sortedWith(amd64Baseline, "getcwd", "stat"), Action: SeccompActionAllow},
+			},
+		}, false)
+	assertThat("should remove duplicated syscalls",
+		[]SystemCall{{ID: 0, Name: "read"}, {ID: 0, Name: "read"}, {ID: 79, Name: "getcwd"}, {ID: 79, Name: "getcwd"}},
+		"amd64",
+		&SeccompProfile{
+			DefaultAction: SeccompActionErrno,
+			Architectures: []string{"SCMP_ARCH_X86_64"},
+			Syscalls: []SeccompSyscall{
+				{Names: sortedWith(amd64Baseline, "getcwd"), Action: SeccompActionAllow},
+			},
+		}, false)
+	assertThat("should map go architecture names",
+		[]SystemCall{}, "arm64",
+		&SeccompProfile{
+			DefaultAction: SeccompActionErrno,
+			Architectures: []string{"SCMP_ARCH_AARCH64"},
+			Syscalls: []SeccompSyscall{
+				{Names: arm64Baseline, Action: SeccompActionAllow},
+			},
+		}, false)
+	assertThat("should error for unsupported architectures",
+		[]SystemCall{}, "mips", nil, true)
+}
+
+func TestNewSeccompProfile_Baseline(t *testing.T) {
+	assertThat := func(assumption string, archs []string, name string, expected bool) {
+		should := should.New(t)
+
+		profile, err := NewSeccompProfile([]SystemCall{{ID: 1, Name: "write"}}, archs...)
+
+		should.NotError(err, assumption)
+		should.BeEqual(expected, containsString(profile.Syscalls[0].Names, name), assumption)
+	}
+
+	assertThat("should always allow execve", []string{"amd64"}, "execve", true)
+	assertThat("should allow the runtime baseline", []string{"amd64"}, "arch_prctl", true)
+	assertThat("should allow the runtime baseline of all go releases", []string{"amd64"}, "pipe2", true)
+	assertThat("should skip baseline syscalls missing in the architecture", []string{"arm64"},
+		"arch_prctl", false)
+	assertThat("should keep baseline syscalls found in any architecture", []string{"arm64", "amd64"},
+		"arch_prctl", true)
+}
+
+// sortedWith returns a sorted copy of names with the extra names.
+func sortedWith(names []string, extra ...string) []string {
+	all := append(append([]string{}, names...), extra...)
+	sort.Strings(all)
+
+	return all
+}
+
+func TestNewSeccompProfile_Architectures(t *testing.T) {
+	assertThat := func(assumption string, archs []string, expected []string, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := NewSeccompProfile([]SystemCall{{ID: 1, Name: "write"}}, archs...)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		if !hasErrored {
+			should.BeEqual(expected, actual.Architectures, assumption)
+		}
+	}
+
+	assertThat("should support multiple architectures", []string{"amd64", "arm64"},
+		[]string{"SCMP_ARCH_X86_64", "SCMP_ARCH_AARCH64"}, false)
+	assertThat("should remove duplicated architectures", []string{"amd64", "amd64"},
+		[]string{"SCMP_ARCH_X86_64"}, false)
+	assertThat("should error when no architecture is provided", []string{}, nil, true)
+	assertThat("should error when any architecture is unsupported", []string{"amd64", "mips"}, nil, true)
+}
+
+func TestSeccompProfile_Write(t *testing.T) {
+	should := should.New(t)
+	var output bytes.Buffer
+	profile := &SeccompProfile{
+		DefaultAction: SeccompActionErrno,
+		Architectures: []string{"SCMP_ARCH_X86_64"},
+		Syscalls:      []SeccompSyscall{{Names: []string{"exit_group"}, Action: SeccompActionAllow}},
+	}
+
+	err := profile.Write(&output)
+
+	should.NotError(err, "should not error when writing profile")
+	should.BeEqual(`{
+  "defaultAction": "SCMP_ACT_ERRNO",
+  "architectures": [
+    "SCMP_ARCH_X86_64"
+  ],
+  "syscalls": [
+    {
+      "names": [
+        "exit_group"
+      ],
+      "action": "SCMP_ACT_ALLOW"
+    }
+  ]
+}
+`, output.String(), "should write profile as indented json")
+}
@@ -0,0 +1,50 @@
+package systract
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// SortOrder defines the order in which system calls are returned.
+type SortOrder string
+
+const (
+	// SortByDiscovery keeps system calls in the order they are found, walking the
+	// call graph from each entry point in turn. This is the default order.
+	SortByDiscovery SortOrder = "discovery"
+	// SortByID sorts system calls by their ID.
+	SortByID SortOrder = "id"
+	// SortByName sorts system calls by their name.
+	SortByName SortOrder = "name"
+)
+
+// SortOrders are all the orders supported.
+var SortOrders = []SortOrder{SortByDiscovery, SortByID, SortByName}
+
+// Sort sorts syscalls in place. Syscalls with the same name are sorted by ID and vice versa,
+// so the order is the same for any extraction of the same file. SortByDiscovery keeps syscalls as they are.
+func Sort(syscalls []SystemCall, order SortOrder) error {
+	switch order {
+	case SortByDiscovery, "":
+		return nil
+	case SortByID:
+		sort.SliceStable(syscalls, func(i, j int) bool {
+			if syscalls[i].ID != syscalls[j].ID {
+				return syscalls[i].ID < syscalls[j].ID
+			}
+			return syscalls[i].Name < syscalls[j].Name
+		})
+	case SortByName:
+		sort.SliceStable(syscalls, func(i, j int) bool {
+			if syscalls[i].Name != syscalls[j].Name {
+				return syscalls[i].Name < syscalls[j].Name
+			}
+			return syscalls[i].ID < syscalls[j].ID
+		})
+	default:
+		return errors.Errorf("invalid sort order: %s", order)
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package systract
+
+import (
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestSort(t *testing.T) {
+	assertThat := func(assumption string, order SortOrder, expected []SystemCall, expectedErr bool) {
+		should := should.New(t)
+		syscalls := []SystemCall{
+			{ID: 231, Name: "exit_group"}, {ID: 1, Name: "write"}, {ID: 0, Name: "read"}, {ID: 1, Name: "exit"},
+		}
+
+		err := Sort(syscalls, order)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		if !expectedErr {
+			should.BeEqual(expected, syscalls, assumption)
+		}
+	}
+
+	assertThat("should keep discovery order", SortByDiscovery,
+		[]SystemCall{{ID: 231, Name: "exit_group"}, {ID: 1, Name: "write"}, {ID: 0, Name: "read"}, {ID: 1, Name: "exit"}}, false)
+	assertThat("should keep discovery order by default", "",
+		[]SystemCall{{ID: 231, Name: "exit_group"}, {ID: 1, Name: "write"}, {ID: 0, Name: "read"}, {ID: 1, Name: "exit"}}, false)
+	assertThat("should sort by id and then name", SortByID,
+		[]SystemCall{{ID: 0, Name: "read"}, {ID: 1, Name: "exit"}, {ID: 1, Name: "write"}, {ID: 231, Name: "exit_group"}}, false)
+	assertThat("should sort by name", SortByName,
+		[]SystemCall{{ID: 1, Name: "exit"}, {ID: 231, Name: "exit_group"}, {ID: 0, Name: "read"}, {ID: 1, Name: "write"}}, false)
+	assertThat("should error for unknown orders", "size", nil, true)
+}
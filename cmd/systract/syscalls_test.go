@@ -0,0 +1,41 @@
+package systract
+
+import (
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+	"github.com/pkg/errors"
+)
+
+func TestLookupSyscall(t *testing.T) {
+	assertThat := func(assumption, arch string, id uint16, expectedName string, expectedFound bool) {
+		should := should.New(t)
+
+		name, found := LookupSyscall(arch, id)
+
+		should.BeEqual(expectedFound, found, assumption)
+		should.BeEqual(expectedName, name, assumption)
+	}
+
+	assertThat("should resolve amd64 syscalls", "amd64", 231, "exit_group", true)
+	assertThat("should resolve arm64 syscalls", "arm64", 94, "exit_group", true)
+	assertThat("should resolve 386 syscalls", "386", 252, "exit_group", true)
+	assertThat("should resolve arm syscalls", "arm", 248, "exit_group", true)
+	assertThat("should resolve ppc64le syscalls", "ppc64le", 234, "exit_group", true)
+	assertThat("should resolve s390x syscalls", "s390x", 248, "exit_group", true)
+	assertThat("should resolve riscv64 syscalls", "riscv64", 94, "exit_group", true)
+	assertThat("should resolve amd64 syscalls used by go 1.20 onwards", "amd64", 439, "faccessat2", true)
+	assertThat("should not resolve amd64 x32 syscalls", "amd64", 520, "", false)
+	assertThat("should not resolve unknown ids", "amd64", 9999, "", false)
+	assertThat("should not resolve unsupported architectures", "mips", 1, "", false)
+}
+
+func TestGetSyscallTable(t *testing.T) {
+	should := should.New(t)
+
+	_, err := getSyscallTable("arm64")
+	should.NotError(err, "should return table for supported architectures")
+
+	_, err = getSyscallTable("mips")
+	should.BeEqual(ErrUnsupportedArch, errors.Cause(err), "should error for unsupported architectures")
+}
@@ -3,32 +3,84 @@ package systract
 
 import (
 	"bufio"
+	"context"
+	"fmt"
 	"io"
 	"regexp"
+	"runtime"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 
-	"github.com/golang-collections/collections/stack"
+	"github.com/pkg/errors"
 )
 
 const (
 	symbolDefinitionRegex     string = "TEXT.((\\%|\\(|\\)|\\*|[a-zA-Z0-9_.\\/])+)\\b\\("
 	initSymbolDefinitionRegex string = "((\\%|\\(|\\)|\\*|[a-zA-Z0-9_.\\/])+\\.init)\\b"
-	syscallHexIDRegex         string = "MOV(Q|L).\\$0x([0-9a-fA-F]+)"
 	callCaptureRegex          string = ".+CALL.(\\b([a-zA-Z0-9_.\\/]|\\.|\\(\\*[a-zA-Z0-9_.\\/]+\\))+\\b)+"
-	syscallCallRegex          string = "SYSCALL|golang.org/x/sys/unix.Syscall|syscall.Syscall"
+	relocationCallRegex       string = "R_CALL:([^\\s]+)"
+
+	// maxLineSize is the longest dump line supported, which is well above
+	// the length of the instructions disassembled by objdump.
+	maxLineSize int = 1024 * 1024
+	// progressInterval is the number of dump lines parsed between progress reports.
+	progressInterval int = 500000
+	// cancelCheckInterval is the number of symbols walked between checks for cancellation.
+	cancelCheckInterval int = 4096
+)
+
+var (
+	symbolDefinitionLine = regexp.MustCompile(symbolDefinitionRegex)
+	initSymbolName       = regexp.MustCompile(initSymbolDefinitionRegex)
+	callCapture          = regexp.MustCompile(callCaptureRegex)
+	relocationCall       = regexp.MustCompile(relocationCallRegex)
 )
 
 // SystemCall represents a system call
 type SystemCall struct {
-	ID   uint16
-	Name string
+	ID   uint16 `json:"id" yaml:"id"`
+	Name string `json:"name" yaml:"name"`
+	// CallPath is the shortest chain of calls from an entry point
+	// to the symbol that makes the system call.
+	CallPath []string `json:"callPath,omitempty" yaml:"callPath,omitempty"`
+	// Packages are the packages whose functions directly issue the system call.
+	Packages []string `json:"packages,omitempty" yaml:"packages,omitempty"`
+	// Runtime defines whether the system call is only made by the go runtime, outside
+	// of the call graph of the entry points. It is only set with WithRuntimeRoots.
+	Runtime bool `json:"runtime,omitempty" yaml:"runtime,omitempty"`
+}
+
+// DefaultEntryPoints are the symbols the call graph is walked from, unless
+// WithEntryPoints is used. The init functions of all packages and the functions
+// exported to C through cgo are always walked.
+var DefaultEntryPoints = []string{"main.main", "main.init.0", "main.init.1"}
+
+type syscallPath struct {
+	id   uint16
+	path []string
 }
 
 type symbolDefinition struct {
 	name       string
 	syscallIDs []uint16
 	subCalls   []string
+	// references are the symbols whose address is taken, only kept in conservative mode.
+	references []string
+	// addressCalls are the calls into addresses, rather than into symbols.
+	addressCalls []addressCall
+	warnings     []Warning
+	// start and end are the addresses of the first and last instructions of the symbol.
+	start, end uint64
+}
+
+// addressCall is a call whose target is an address, which objdump emits
+// for calls that do not target the start of a symbol.
+type addressCall struct {
+	target   uint64
+	location string
+	address  string
 }
 
 // SourceReader defines the interface for source readers
@@ -36,174 +88,462 @@ type SourceReader interface {
 	GetReader() (io.ReadCloser, error)
 }
 
-// Extract returns all system calls made in the execution path of the dumpFile provided.
-func Extract(source SourceReader) ([]SystemCall, error) {
-	reader, err := source.GetReader()
+// Extract returns all system calls made in the execution path of the application read by source.
+// It is a shorthand for NewExtractor(opts...).Extract, returning only the system calls found.
+func Extract(source SourceReader, opts ...Option) ([]SystemCall, error) {
+	return ExtractContext(context.Background(), source, opts...)
+}
+
+// ExtractContext works as Extract, stopping once ctx is done and returning its error.
+// Sources that implement ContextSourceReader stop reading as well, e.g. by killing objdump.
+func ExtractContext(ctx context.Context, source SourceReader, opts ...Option) ([]SystemCall, error) {
+	result, err := NewExtractor(opts...).Extract(ctx, source)
 	if err != nil {
 		return nil, err
 	}
-	defer reader.Close()
-
-	symbols := parseDump(reader)
-	syscalls := extractSyscalls(symbols)
 
-	return syscalls, nil
+	return result.Syscalls, nil
 }
 
-func getEntryPoints(symbols map[string]symbolDefinition) (ep []string) {
-	ep = append(ep, "main.main", "main.init.0", "main.init.1")
-	ep = append(ep, extractInitSymbols(symbols)...)
-	return
+// ExtractResult works as Extract, returning the complete Result of the extraction,
+// including its warnings and metadata.
+func ExtractResult(source SourceReader, opts ...Option) (*Result, error) {
+	return NewExtractor(opts...).Extract(context.Background(), source)
 }
 
 // kick off process from executable key entry points.
-func extractSyscalls(symbols map[string]symbolDefinition) []SystemCall {
-	syscallID := make(chan uint16)
+// Walkers are limited to the number of CPUs, as each one keeps track of
+// the callers of every symbol in the graph. Their results are merged in
+// the order of the entry points, so syscalls are always in the same order.
+func extractSyscalls(ctx context.Context, graph *callGraph, entryPoints []string,
+	table map[uint16]string) ([]SystemCall, error) {
+	limit := make(chan struct{}, runtime.NumCPU())
+	found := make([][]syscallPath, len(entryPoints))
 
 	var wg sync.WaitGroup
-	entryPoints := getEntryPoints(symbols)
 	wg.Add(len(entryPoints))
-	for _, symbol := range entryPoints {
-		go func(s string) {
-			dumpWalker(symbols, s, syscallID)
+	for i, symbol := range entryPoints {
+		go func(i int, s string) {
+			limit <- struct{}{}
+			found[i] = dumpWalker(ctx, graph, s)
+			<-limit
 			wg.Done()
-		}(symbol)
+		}(i, symbol)
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	syscalls := make([]SystemCall, 0)
+	unique := make(map[uint16]int)
+
+	for _, f := range flatten(found) {
+		if i, exists := unique[f.id]; exists {
+			if isShorterPath(f.path, syscalls[i].CallPath) {
+				syscalls[i].CallPath = f.path
+			}
+			continue
+		}
+
+		unique[f.id] = len(syscalls)
+		syscalls = append(syscalls, SystemCall{
+			ID:       f.id,
+			Name:     table[f.id],
+			CallPath: f.path,
+		})
+	}
+
+	attributePackages(graph, entryPoints, syscalls)
+	return syscalls, nil
+}
+
+// isShorterPath compares call paths by length, and then alphabetically,
+// so the same path is picked regardless of the order walkers report them.
+func isShorterPath(path, current []string) bool {
+	if len(path) != len(current) {
+		return len(path) < len(current)
+	}
+
+	return strings.Join(path, " ") < strings.Join(current, " ")
+}
+
+// dumpParser parses dumps into call graphs, parsing their functions concurrently.
+type dumpParser struct {
+	abi      abiSpec
+	wrappers map[string]SyscallWrapper
+	table    map[uint16]string
+	// jobs is the number of functions parsed concurrently.
+	jobs int
+	// progress is called every progressInterval lines and once parsing is done, when set.
+	progress func(Progress)
+	// references defines whether the functions whose address is taken are linked as calls.
+	references bool
+	// stubs are the linker stubs calling into shared libraries, whose calls are not followed.
+	stubs []AddressRange
+}
+
+// dumpChunk holds the lines of a single function of a dump.
+type dumpChunk struct {
+	seq   int
+	name  string
+	lines []string
+}
+
+type parsedSymbol struct {
+	seq    int
+	name   string
+	symbol symbolDefinition
+}
+
+// parse splits the dump into functions, which are parsed by a pool of workers.
+// The results are merged into the call graph in the order the functions appear in the dump,
+// so the graph is the same regardless of the number of workers. Parsing stops once ctx is done.
+func (p *dumpParser) parse(ctx context.Context, reader io.Reader) (*callGraph, error) {
+	jobs := p.jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	chunks := make(chan dumpChunk, jobs*2)
+	parsed := make(chan parsedSymbol, jobs*2)
+
+	var progress Progress
+	var readErr error
+	go func() {
+		progress, readErr = p.split(ctx, reader, chunks)
+		close(chunks)
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(jobs)
+	for i := 0; i < jobs; i++ {
+		go func() {
+			defer wg.Done()
+			for c := range chunks {
+				parsed <- parsedSymbol{seq: c.seq, name: c.name, symbol: p.parseSymbol(c.lines)}
+			}
+		}()
 	}
 
 	go func() {
 		wg.Wait()
-		close(syscallID)
+		close(parsed)
 	}()
 
-	syscalls := make([]SystemCall, 0)
-	unique := make(map[uint16]bool)
+	graph := newCallGraph()
+	references := make(map[int32][]string)
+	var ranges []symbolRange
+	var addressCalls []symbolAddressCalls
+	pending := make(map[int]parsedSymbol)
+	next := 0
+	for s := range parsed {
+		pending[s.seq] = s
+		for {
+			s, exists := pending[next]
+			if !exists {
+				break
+			}
 
-	for id := range syscallID {
-		if _, exists := unique[id]; !exists {
-			unique[id] = true
-			syscalls = append(syscalls, SystemCall{
-				ID:   id,
-				Name: systemCalls[id],
-			})
+			delete(pending, next)
+			next++
+			if s.symbol.end > 0 {
+				ranges = append(ranges, symbolRange{start: s.symbol.start, end: s.symbol.end, name: s.name})
+			}
+			if len(s.symbol.subCalls) > 0 || len(s.symbol.syscallIDs) > 0 || len(s.symbol.references) > 0 ||
+				len(s.symbol.addressCalls) > 0 || len(s.symbol.warnings) > 0 {
+				graph.add(s.name, s.symbol)
+			}
+			if len(s.symbol.references) > 0 {
+				i, _ := graph.lookup(s.name)
+				references[i] = append(references[i], s.symbol.references...)
+			}
+			if len(s.symbol.addressCalls) > 0 {
+				i, _ := graph.lookup(s.name)
+				addressCalls = append(addressCalls, symbolAddressCalls{caller: i, calls: s.symbol.addressCalls})
+			}
 		}
 	}
+	graph.linkReferences(references)
+	graph.linkAddressCalls(ranges, addressCalls, p.stubs)
+
+	if readErr != nil {
+		return nil, errors.Wrap(readErr, "could not read dump")
+	}
 
-	return syscalls
+	progress.Done = true
+	if p.progress != nil {
+		p.progress(progress)
+	}
+
+	return graph, nil
 }
 
-func parseDump(reader io.Reader) map[string]symbolDefinition {
-	symbols := make(map[string]symbolDefinition)
+// split reads the dump line by line, sending the lines of each function into chunks.
+func (p *dumpParser) split(ctx context.Context, reader io.Reader, chunks chan<- dumpChunk) (Progress, error) {
+	var progress Progress
 	scanner := bufio.NewScanner(reader)
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		stack := stack.New()
-		symbol := symbolDefinition{
-			subCalls:   make([]string, 0),
-			syscallIDs: make([]uint16, 0),
-		}
-		symbolName, found := getSymbolName(line)
-
-		for found {
-			if scanner.Scan() {
-				line = scanner.Text()
-				if isEndOfSymbol(line) {
-					break
-				}
-
-				if id, found := tryPopSyscallID(line, stack); found {
-					symbol.syscallIDs = append(symbol.syscallIDs, id)
-					continue
-				}
-
-				if subcall, found := getCallTarget(line); found {
-					symbol.subCalls = append(symbol.subCalls, subcall)
-					continue
-				}
-
-				stackSyscallIDIfNecessary(line, stack)
-			} else {
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	scan := func() bool {
+		if !scanner.Scan() {
+			return false
+		}
+
+		progress.Lines++
+		if p.progress != nil && progress.Lines%progressInterval == 0 {
+			p.progress(progress)
+		}
+		return true
+	}
+
+	for scan() {
+		symbolName, found := getSymbolName(scanner.Text())
+		if !found {
+			continue
+		}
+
+		chunk := dumpChunk{seq: progress.Symbols, name: symbolName}
+		for scan() {
+			line := scanner.Text()
+			if isEndOfSymbol(line) {
 				break
 			}
+			chunk.lines = append(chunk.lines, line)
+		}
+
+		progress.Symbols++
+		chunks <- chunk
+
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+	}
+
+	return progress, scanner.Err()
+}
+
+// parseSymbol returns the syscall IDs and calls found in the lines of a function.
+func (p *dumpParser) parseSymbol(lines []string) symbolDefinition {
+	tracker := newRegisterTracker(p.abi)
+	var symbol symbolDefinition
+
+	for _, line := range lines {
+		instruction := getInstruction(line)
+		id, found, warning := getSyscallID(line, instruction, tracker, p.wrappers, p.table)
+		if warning != nil {
+			warning.Location, warning.Address = getLineLocation(line)
+			symbol.warnings = append(symbol.warnings, *warning)
+		}
+		if found {
+			symbol.syscallIDs = append(symbol.syscallIDs, id)
+			tracker.track(instruction)
+			continue
 		}
 
-		if len(symbol.subCalls) > 0 || len(symbol.syscallIDs) > 0 {
-			symbols[symbolName] = symbol
+		if target, isAddress := getAddressCallTarget(instruction); isAddress {
+			location, address := getLineLocation(line)
+			symbol.addressCalls = append(symbol.addressCalls, addressCall{target: target, location: location, address: address})
+		} else if subcall, found := getCallTarget(line); found {
+			symbol.subCalls = append(symbol.subCalls, subcall)
+		} else if p.references {
+			symbol.references = append(symbol.references, getReferences(instruction)...)
 		}
+
+		tracker.track(instruction)
+	}
+
+	if len(lines) > 0 {
+		symbol.start = getLineAddress(lines[0])
+		symbol.end = getLineAddress(lines[len(lines)-1])
 	}
 
-	return symbols
+	return symbol
 }
 
-func dumpWalker(symbols map[string]symbolDefinition, symbolName string, syscallID chan<- uint16) {
-	var walk func(symbol string)
-	processed := make(map[string]bool)
+func flatten(found [][]syscallPath) []syscallPath {
+	all := make([]syscallPath, 0)
+	for _, f := range found {
+		all = append(all, f...)
+	}
 
-	walk = func(symbol string) {
-		if _, exists := processed[symbol]; !exists {
-			processed[symbol] = true
-			if s, found := symbols[symbol]; found {
-				for _, id := range s.syscallIDs {
-					syscallID <- id
-				}
+	return all
+}
 
-				for _, name := range s.subCalls {
-					walk(name)
-				}
+// dumpWalker walks the call graph breadth-first from symbolNames, returning
+// each system call found alongside the shortest call path that reaches it,
+// in the order they were found. The walk stops once ctx is done.
+func dumpWalker(ctx context.Context, graph *callGraph, symbolNames ...string) []syscallPath {
+	found := make([]syscallPath, 0)
+
+	// callers holds the index of the caller of each symbol visited plus one,
+	// so zero represents symbols not visited yet. The symbols the walk starts
+	// from are their own callers.
+	callers := make([]int32, graph.len())
+	queue := make([]int32, 0, len(symbolNames))
+	for _, name := range symbolNames {
+		if start, exists := graph.lookup(name); exists && callers[start] == 0 {
+			callers[start] = start + 1
+			queue = append(queue, start)
+		}
+	}
+	reported := make(map[uint16]bool)
+
+	for walked := 1; len(queue) > 0; walked++ {
+		if walked%cancelCheckInterval == 0 && ctx.Err() != nil {
+			break
+		}
+
+		symbol := queue[0]
+		queue = queue[1:]
+
+		for _, id := range graph.syscalls[symbol] {
+			if !reported[id] {
+				reported[id] = true
+				found = append(found, syscallPath{id: id, path: getCallPath(graph, callers, symbol)})
+			}
+		}
+
+		for _, target := range graph.calls[symbol] {
+			if callers[target] == 0 {
+				callers[target] = symbol + 1
+				queue = append(queue, target)
 			}
 		}
 	}
 
-	walk(symbolName)
+	return found
 }
 
-func stackSyscallIDIfNecessary(assemblyLine string, s *stack.Stack) {
-	if id, ok := getSyscallID(assemblyLine); ok {
-		s.Push(id)
+func getCallPath(graph *callGraph, callers []int32, symbol int32) []string {
+	path := []string{graph.names[symbol]}
+	for s := symbol; callers[s]-1 != s; {
+		s = callers[s] - 1
+		path = append([]string{graph.names[s]}, path...)
 	}
+
+	return path
 }
 
-func tryPopSyscallID(assemblyLine string, s *stack.Stack) (uint16, bool) {
-	if s.Len() > 0 && containsSyscall(assemblyLine) {
-		val1 := s.Pop()
-		val2 := s.Pop()
+// getSyscallID returns the syscall ID dispatched by assemblyLine, as long as its value
+// can be resolved and exists in the syscall table. Otherwise, when assemblyLine dispatches
+// a syscall, a warning is returned with the reason it was dropped.
+func getSyscallID(assemblyLine, instruction string, tracker *registerTracker,
+	wrappers map[string]SyscallWrapper, table map[uint16]string) (uint16, bool, *Warning) {
+	var n uint64
+	var resolved bool
+
+	if isSyscallInstruction(instruction) {
+		n, resolved = tracker.syscallRegisterValue()
+	} else if wrapper, isWrapper := getSyscallWrapper(assemblyLine, wrappers); isWrapper {
+		if wrapper.Syscall != "" {
+			if id, found := lookupSyscallID(table, wrapper.Syscall); found {
+				return id, true, nil
+			}
+			return 0, false, &Warning{Kind: WarningUnknownSyscall,
+				Message: fmt.Sprintf("unknown system call %s", wrapper.Syscall)}
+		}
+		n, resolved = tracker.argument(wrapper.ArgIndex)
+	} else {
+		return 0, false, nil
+	}
 
-		syscallID := val1
-		if val2 != nil {
-			syscallID = val2
+	if !resolved {
+		return 0, false, &Warning{Kind: WarningUnresolvedSyscall, Message: "could not resolve the system call number"}
+	}
+
+	if n <= 0xffff {
+		id := uint16(n)
+		if _, exists := table[id]; exists {
+			return id, true, nil
 		}
-		return syscallID.(uint16), true
 	}
 
-	return 0, false
+	return 0, false, &Warning{Kind: WarningUnknownSyscall, Message: fmt.Sprintf("unknown system call ID %#x", n)}
 }
 
-func getSyscallID(assemblyLine string) (uint16, bool) {
-	re := regexp.MustCompile(syscallHexIDRegex)
-	captures := re.FindStringSubmatch(assemblyLine)
+// getAddressCallTarget returns the target of calls into addresses, e.g. "CALL 0x4542cc".
+func getAddressCallTarget(instruction string) (uint64, bool) {
+	if !strings.HasPrefix(instruction, "CALL 0x") {
+		return 0, false
+	}
 
-	if captures != nil && len(captures) > 0 {
-		if n, err := strconv.ParseUint(captures[2], 16, 16); err == nil {
-			id := uint16(n)
-			if _, exists := systemCalls[id]; exists {
-				return id, true
-			}
+	target, err := strconv.ParseUint(strings.TrimPrefix(instruction, "CALL "), 0, 64)
+	return target, err == nil
+}
+
+// getLineAddress returns the address of the instruction in assemblyLine, or 0 when it has none.
+func getLineAddress(assemblyLine string) uint64 {
+	_, address := getLineLocation(assemblyLine)
+	n, err := strconv.ParseUint(address, 0, 64)
+	if err != nil {
+		return 0
+	}
+
+	return n
+}
+
+// getSyscallWrapper returns the syscall wrapper called in assemblyLine, if any.
+func getSyscallWrapper(assemblyLine string, wrappers map[string]SyscallWrapper) (SyscallWrapper, bool) {
+	if !strings.Contains(assemblyLine, "CALL") {
+		return SyscallWrapper{}, false
+	}
+
+	target, found := getCallTarget(assemblyLine)
+	if !found {
+		target, found = extract(assemblyLine, relocationCall)
+	}
+
+	if found {
+		wrapper, exists := wrappers[strings.TrimSuffix(target, ".abi0")]
+		return wrapper, exists
+	}
+
+	return SyscallWrapper{}, false
+}
+
+// getReader uses ctx for sources that implement ContextSourceReader.
+func getReader(ctx context.Context, source SourceReader) (io.ReadCloser, error) {
+	if r, ok := source.(ContextSourceReader); ok {
+		return r.GetReaderContext(ctx)
+	}
+
+	return source.GetReader()
+}
+
+// lookupSyscallID returns the lowest ID of the syscall name, as some tables
+// define the same syscall more than once, e.g. the x32 ABI ones in amd64.
+func lookupSyscallID(table map[uint16]string, name string) (uint16, bool) {
+	var lowest uint16
+	found := false
+	for id, n := range table {
+		if n == name && (!found || id < lowest) {
+			lowest, found = id, true
 		}
 	}
 
-	return 0, false
+	return lowest, found
 }
 
+// getSymbolName and getCallTarget check for the instruction before
+// matching the regular expressions, which are far more expensive.
 func getSymbolName(assemblyLine string) (string, bool) {
-	return extract(assemblyLine, symbolDefinitionRegex)
+	if !strings.Contains(assemblyLine, "TEXT") {
+		return "", false
+	}
+
+	return extract(assemblyLine, symbolDefinitionLine)
 }
 
 func getCallTarget(assemblyLine string) (string, bool) {
-	return extract(assemblyLine, callCaptureRegex)
+	if !strings.Contains(assemblyLine, "CALL") {
+		return "", false
+	}
+
+	return extract(assemblyLine, callCapture)
 }
 
-func extract(assemblyLine, regex string) (string, bool) {
-	re := regexp.MustCompile(regex)
+func extract(assemblyLine string, re *regexp.Regexp) (string, bool) {
 	captures := re.FindStringSubmatch(assemblyLine)
 
 	if captures != nil && len(captures) > 0 {
@@ -213,29 +553,23 @@ func extract(assemblyLine, regex string) (string, bool) {
 	return "", false
 }
 
-func containsSyscall(assemblyLine string) bool {
-	re := regexp.MustCompile(syscallCallRegex)
-	captures := re.FindStringSubmatch(assemblyLine)
-
-	return (captures != nil && len(captures) > 0)
-}
-
 func isEndOfSymbol(line string) bool {
 	return (line == "" || line == "\n")
 }
 
 func isInitSymbol(line string) bool {
-	re := regexp.MustCompile(initSymbolDefinitionRegex)
-	captures := re.FindStringSubmatch(line)
+	captures := initSymbolName.FindStringSubmatch(line)
 
 	return (captures != nil && len(captures) > 0)
 }
 
-func extractInitSymbols(symbols map[string]symbolDefinition) (initSymbols []string) {
-	for k := range symbols {
-		if isInitSymbol(k) {
-			initSymbols = append(initSymbols, k)
+// extractInitSymbols returns the init functions in the graph, sorted by name.
+func extractInitSymbols(graph *callGraph) (initSymbols []string) {
+	for _, name := range graph.names {
+		if isInitSymbol(name) {
+			initSymbols = append(initSymbols, name)
 		}
 	}
+	sort.Strings(initSymbols)
 	return
 }
@@ -1,11 +1,14 @@
 package systract
 
 import (
+	"context"
 	"path/filepath"
+	"sort"
+	"strings"
 	"testing"
 
-	"github.com/golang-collections/collections/stack"
 	"github.com/pjbgf/go-test/should"
+	"github.com/pkg/errors"
 )
 
 func TestExtract_Errors(t *testing.T) {
@@ -44,17 +47,78 @@ func TestExtract_E2E_Executable(t *testing.T) {
 }
 
 func TestGetSyscallID(t *testing.T) {
-	assertThat := func(assumption, assemblyLine string, expectedId uint16, expectedMatch bool) {
+	assertThat := func(assumption string, assemblyLines []string, expectedId uint16, expectedMatch bool,
+		expectedWarning WarningKind) {
 		should := should.New(t)
+		tracker := newRegisterTracker(abiSpecs["amd64"])
+		last := len(assemblyLines) - 1
+		for _, line := range assemblyLines[:last] {
+			tracker.track(getInstruction(line))
+		}
 
-		id, ok := getSyscallID(assemblyLine)
+		id, ok, warning := getSyscallID(assemblyLines[last], getInstruction(assemblyLines[last]), tracker,
+			DefaultCatalog.index(), amd64SystemCalls)
 
 		should.BeEqual(expectedMatch, ok, assumption)
 		should.BeEqual(expectedId, id, assumption)
+		var kind WarningKind
+		if warning != nil {
+			kind = warning.Kind
+		}
+		should.BeEqual(expectedWarning, kind, assumption)
 	}
 
-	assertThat("should support golang.org/x/sys/unix.Syscall calls", "zsyscall_linux_amd64.go:442	0x48bd75		48c704247d000000	MOVQ $0x7d, 0(SP)", 125, true)
-	assertThat("should support SYSCALL calls", "sys_linux_amd64.s:625	0x453610		b818000000		MOVL $0x18, AX", 24, true)
+	assertThat("should support golang.org/x/sys/unix.Syscall calls", []string{
+		"zsyscall_linux_amd64.go:442	0x48bd75		48c704247d000000	MOVQ $0x7d, 0(SP)",
+		"zsyscall_linux_amd64.go:442	0x48bd9a		e881030000		CALL golang.org/x/sys/unix.Syscall(SB)",
+	}, 125, true, "")
+	assertThat("should support SYSCALL calls", []string{
+		"sys_linux_amd64.s:625	0x453610		b818000000		MOVL $0x18, AX",
+		"sys_linux_amd64.s:626	0x453615		0f05			SYSCALL",
+	}, 24, true, "")
+	assertThat("should not match ids outside of the syscall table", []string{
+		"sys_linux_amd64.s:616	0x453aa5		48c7c002100000		MOVQ $0x1002, AX",
+		"sys_linux_amd64.s:626	0x453615		0f05			SYSCALL",
+	}, 0, false, WarningUnknownSyscall)
+	assertThat("should support wrappers with fixed syscalls", []string{
+		"time_linux.go:10	0x453610		e8c334fcff		CALL runtime.nanotime1(SB)",
+	}, 228, true, "")
+	assertThat("should support ABIInternal calls to syscall.RawSyscall", []string{
+		"exec_linux.go:567	0x453610		b839000000		MOVL $0x39, AX",
+		"exec_linux.go:567	0x453615		31db			XORL BX, BX",
+		"exec_linux.go:567	0x453617		e8c334fcff		CALL syscall.RawSyscall(SB)",
+	}, 57, true, "")
+	assertThat("should not match lines without syscalls", []string{
+		"sys_linux_amd64.s:625	0x453610		b818000000		MOVL $0x18, AX",
+		"main.go:35		0x48c3d8		e8c334fcff		CALL runtime.morestack_noctxt(SB)",
+	}, 0, false, "")
+	assertThat("should warn when syscall number cannot be resolved", []string{
+		"sys_linux_amd64.s:625	0x453610		488b442408		MOVQ 0x8(SP), AX",
+		"sys_linux_amd64.s:626	0x453615		0f05			SYSCALL",
+	}, 0, false, WarningUnresolvedSyscall)
+	assertThat("should warn when wrapper argument cannot be resolved", []string{
+		"zsyscall_linux_amd64.go:442	0x48bd9a		e881030000		CALL golang.org/x/sys/unix.Syscall(SB)",
+	}, 0, false, WarningUnresolvedSyscall)
+}
+
+func TestExtract_Architectures(t *testing.T) {
+	assertThat := func(assumption string, opts []Option, expected []SystemCall, expectedErr bool) {
+		should := should.New(t)
+
+		actual, err := Extract(NewDumpReader("../../test/single-syscall.dump"), opts...)
+
+		hasErrored := err != nil
+		should.BeEqual(expectedErr, hasErrored, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should default to amd64 for dump files", nil,
+		[]SystemCall{{ID: 231, Name: "exit_group", CallPath: []string{"main.main"}, Packages: []string{"main"}}}, false)
+	assertThat("should resolve ids against the architecture provided",
+		[]Option{WithArchitecture("386")},
+		[]SystemCall{{ID: 231, Name: "fgetxattr", CallPath: []string{"main.main"}, Packages: []string{"main"}}}, false)
+	assertThat("should error for unsupported architectures",
+		[]Option{WithArchitecture("mips")}, nil, true)
 }
 
 func TestIsCallInstruction(t *testing.T) {
@@ -99,51 +163,53 @@ func TestIsEndOfSymbolDefinition(t *testing.T) {
 	assertThat("should not match empty symbol definition line", "TEXT sync.(*Pool).Get(SB) /usr/local/go/src/sync/pool.go", false)
 }
 
-func TestContainsSyscall(t *testing.T) {
-	assertThat := func(assumption, assemblyLine string, expected bool) {
+func TestDumpParser_SyscallIDs(t *testing.T) {
+	assertThat := func(assumption, dump string, expected []uint16) {
 		should := should.New(t)
-		containsSyscall := containsSyscall(assemblyLine)
-
-		should.BeEqual(expected, containsSyscall, assumption)
-	}
-
-	assertThat("should return true for syscall.Syscall instruction", "zsyscall_linux_amd64.go:310	0x47d56b		e8a0070000		CALL syscall.Syscall(SB)", true)
-	assertThat("should return true for syscall.Syscall6 instruction", "sys_linux.go:149	0x4950b7		e8d48cfeff		CALL syscall.Syscall6(SB)		", true)
-	assertThat("should return true for SYSCALL instruction", "sys_linux_amd64.s:535	0x4534f1		0f05			SYSCALL", true)
-	assertThat("should return true for golang.org/x/sys/unix.Syscall instruction", "zsyscall_linux_amd64.go:442	0x48bd9a		e881030000		CALL golang.org/x/sys/unix.Syscall(SB)", true)
-	assertThat("should return false for instructions containing syscall on their name", "proc.go:2853		0x430ab3		eb8b			JMP runtime.entersyscall_sysmon(SB)", false)
-}
 
-func TestTryPopSyscallID(t *testing.T) {
-	assertThat := func(assumption, assemblyLine string, expectedID uint16, s *stack.Stack, expectedMatch bool) {
-		should := should.New(t)
-		actual, ok := tryPopSyscallID(assemblyLine, s)
+		graph, err := newAmd64Parser(1).parse(context.Background(), strings.NewReader(dump))
 
-		should.BeEqual(expectedMatch, ok, assumption)
-		should.BeEqual(expectedID, actual, assumption)
+		should.NotError(err, assumption)
+		should.BeEqual(expected, graph.symbol("main.f").syscallIDs, assumption)
 	}
 
-	stack := stack.New()
-	stackSyscallIDIfNecessary("zsyscall_linux_amd64.go:442	0x48bd75		48c704247d000000	MOVQ $0x7d, 0(SP)				", stack)
-	stackSyscallIDIfNecessary("zsyscall_linux_amd64.go:442	0x48bd7d		488b442440		MOVQ 0x40(SP), AX				", stack)
-	stackSyscallIDIfNecessary("zsyscall_linux_amd64.go:442	0x48bd82		4889442408		MOVQ AX, 0x8(SP)					", stack)
-	stackSyscallIDIfNecessary("zsyscall_linux_amd64.go:442	0x48bd91		48c744241800000000	MOVQ $0x0, 0x18(SP)				", stack)
-
-	assertThat("should match main.main symbol", "zsyscall_linux_amd64.go:442	0x48bd9a		e881030000		CALL golang.org/x/sys/unix.Syscall(SB)", uint16(125), stack, true)
-}
-
-func TestStackSyscallIDIfNecessary(t *testing.T) {
-	should := should.New(t)
-	stack := stack.New()
-	stackSyscallIDIfNecessary("zsyscall_linux_amd64.go:442	0x48bd75		48c704247d000000	MOVQ $0x7d, 0(SP)", stack)
-	stackSyscallIDIfNecessary("sys_linux.go:230	0x49554e		48c70424fa000000		MOVQ $0xfa, 0(SP)", stack)
-	stackSyscallIDIfNecessary("sys_linux_amd64.s:616	0x453aa5		48c7c702100000		MOVQ $0x1002, DI", stack)
-	stackSyscallIDIfNecessary("sys_linux_amd64.s:617	0x453aac		48c7c09e000000		MOVQ $0x9e, AX", stack)
-
-	should.BeEqual(3, stack.Len(), "should only stack potential ids")
-	should.BeEqual(uint16(158), stack.Pop(), "should match ids in the correct order")
-	should.BeEqual(uint16(250), stack.Pop(), "should match ids in the correct order")
-	should.BeEqual(uint16(125), stack.Pop(), "should match ids in the correct order")
+	assertThat("should resolve ABI0 syscall numbers pushed on the stack", `TEXT main.f(SB) main.go
+  zsyscall_linux_amd64.go:442	0x48bd75		48c704247d000000	MOVQ $0x7d, 0(SP)
+  zsyscall_linux_amd64.go:442	0x48bd7d		488b442440		MOVQ 0x40(SP), AX
+  zsyscall_linux_amd64.go:442	0x48bd82		4889442408		MOVQ AX, 0x8(SP)
+  zsyscall_linux_amd64.go:442	0x48bd91		48c744241800000000	MOVQ $0x0, 0x18(SP)
+  zsyscall_linux_amd64.go:442	0x48bd9a		e881030000		CALL golang.org/x/sys/unix.Syscall(SB)
+`, []uint16{125})
+	assertThat("should resolve ABIInternal syscall numbers set with XORL", `TEXT main.f(SB) main.go
+  zsyscall_linux_amd64.go:696	0x4a1e20		31c0			XORL AX, AX
+  zsyscall_linux_amd64.go:696	0x4a1e22		4889d9			MOVQ BX, CX
+  zsyscall_linux_amd64.go:696	0x4a1e25		e8f6f9ffff		CALL syscall.Syscall(SB)
+`, []uint16{0})
+	assertThat("should resolve syscall numbers moved across registers", `TEXT main.f(SB) main.go
+  sys_linux_amd64.s:616	0x453aa5		48c7c102000000		MOVQ $0x2, CX
+  sys_linux_amd64.s:617	0x453aac		4889c8			MOVQ CX, AX
+  sys_linux_amd64.s:618	0x453aaf		0f05			SYSCALL
+`, []uint16{2})
+	assertThat("should resolve syscall numbers loaded with LEAQ", `TEXT main.f(SB) main.go
+  sys_linux_amd64.s:616	0x453aa5		488d042527000000	LEAQ 0x27, AX
+  sys_linux_amd64.s:618	0x453aaf		0f05			SYSCALL
+`, []uint16{39})
+	assertThat("should resolve syscall numbers stored on the stack before the call", `TEXT main.f(SB) main.go
+  syscall.go:10	0x453aa5		48c744241048000000	MOVQ $0x48, 0x10(SP)
+  syscall.go:11	0x453aae		488b442410		MOVQ 0x10(SP), AX
+  syscall.go:11	0x453ab3		48890424		MOVQ AX, 0(SP)
+  syscall.go:11	0x453ab7		e8a6e6ffff		CALL syscall.Syscall6(SB)
+`, []uint16{72})
+	assertThat("should not use values set before previous calls", `TEXT main.f(SB) main.go
+  syscall.go:10	0x453aa5		b818000000		MOVL $0x18, AX
+  syscall.go:11	0x453aaa		e8c334fcff		CALL runtime.entersyscall(SB)
+  syscall.go:12	0x453aaf		0f05			SYSCALL
+`, nil)
+	assertThat("should not use overwritten values", `TEXT main.f(SB) main.go
+  syscall.go:10	0x453aa5		b818000000		MOVL $0x18, AX
+  syscall.go:11	0x453aaa		4801d8			ADDQ BX, AX
+  syscall.go:12	0x453aaf		0f05			SYSCALL
+`, nil)
 }
 
 func TestIsInitSymbol(t *testing.T) {
@@ -167,7 +233,7 @@ func TestExtractInitSymbols(t *testing.T) {
 	assertThat := func(assumption string, symbols map[string]symbolDefinition, expected []string) {
 		should := should.New(t)
 
-		initSymbols := extractInitSymbols(symbols)
+		initSymbols := extractInitSymbols(graphOf(symbols))
 
 		should.HaveSameItems(expected, initSymbols, assumption)
 	}
@@ -181,3 +247,119 @@ func TestExtractInitSymbols(t *testing.T) {
 			"reflect.(*funcTypeFixed64).Out":                symbolDefinition{},
 		}, []string{"runtime.(*gcWork).init", "github.com/pjbgf/gosystract/cmd/systract.init"})
 }
+
+func TestExtractSyscalls_CallPath(t *testing.T) {
+	should := should.New(t)
+	symbols := map[string]symbolDefinition{
+		"main.main":            {subCalls: []string{"net.Dial", "os.Exit"}},
+		"net.Dial":             {subCalls: []string{"net.socket", "os.Exit"}},
+		"net.socket":           {syscallIDs: []uint16{41}},
+		"os.Exit":              {subCalls: []string{"syscall.Exit"}},
+		"syscall.Exit":         {syscallIDs: []uint16{231}},
+		"main.init.0":          {subCalls: []string{"net.socket"}},
+		"unreachable.func":     {syscallIDs: []uint16{101}},
+		"main.init.1":          {subCalls: []string{"internal/poll.socket"}},
+		"internal/poll.socket": {syscallIDs: []uint16{41}},
+	}
+
+	actual, err := extractSyscalls(context.Background(), graphOf(symbols), entryPointsOf(graphOf(symbols)), amd64SystemCalls)
+	sortSyscallsByID(actual)
+
+	should.NotError(err, "should extract syscalls")
+
+	should.BeEqual([]SystemCall{
+		{ID: 41, Name: "socket", CallPath: []string{"main.init.0", "net.socket"},
+			Packages: []string{"internal/poll", "net"}},
+		{ID: 231, Name: "exit_group", CallPath: []string{"main.main", "os.Exit", "syscall.Exit"},
+			Packages: []string{"syscall"}},
+	}, actual, "should report the shortest call path and packages for each syscall")
+}
+
+func TestExtractSyscalls_Deterministic(t *testing.T) {
+	should := should.New(t)
+	symbols := map[string]symbolDefinition{
+		"main.main":   {subCalls: []string{"os.Write", "os.Exit"}},
+		"os.Write":    {syscallIDs: []uint16{1}},
+		"os.Exit":     {syscallIDs: []uint16{231}},
+		"b.init":      {syscallIDs: []uint16{0, 1}},
+		"a.init":      {syscallIDs: []uint16{3}},
+		"main.init.0": {syscallIDs: []uint16{39}},
+	}
+
+	for i := 0; i < 20; i++ {
+		actual, err := extractSyscalls(context.Background(), graphOf(symbols), entryPointsOf(graphOf(symbols)), amd64SystemCalls)
+		should.NotError(err, "should extract syscalls")
+
+		names := make([]string, 0, len(actual))
+		for _, s := range actual {
+			names = append(names, s.Name)
+		}
+		should.BeEqual([]string{"write", "exit_group", "getpid", "close", "read"}, names,
+			"should return syscalls in the order they are found from each entry point")
+	}
+}
+
+func TestDumpWalker(t *testing.T) {
+	should := should.New(t)
+	symbols := map[string]symbolDefinition{
+		"main.main":  {subCalls: []string{"main.a", "main.b"}},
+		"main.a":     {subCalls: []string{"main.c"}},
+		"main.b":     {subCalls: []string{"main.main", "main.d"}},
+		"main.c":     {subCalls: []string{"main.d"}},
+		"main.d":     {syscallIDs: []uint16{1, 1}},
+		"main.other": {syscallIDs: []uint16{2}},
+	}
+	actual := dumpWalker(context.Background(), graphOf(symbols), "main.main")
+
+	should.BeEqual([]syscallPath{
+		{id: 1, path: []string{"main.main", "main.b", "main.d"}},
+	}, actual, "should report each syscall once with the shortest path")
+}
+
+func TestIsShorterPath(t *testing.T) {
+	assertThat := func(assumption string, path, current []string, expected bool) {
+		should := should.New(t)
+
+		actual := isShorterPath(path, current)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should prefer shorter paths", []string{"a"}, []string{"a", "b"}, true)
+	assertThat("should not prefer longer paths", []string{"a", "b"}, []string{"a"}, false)
+	assertThat("should break ties alphabetically", []string{"a", "b"}, []string{"a", "c"}, true)
+	assertThat("should not prefer equal paths", []string{"a", "b"}, []string{"a", "b"}, false)
+}
+
+// entryPointsOf returns the default entry points of graph.
+func entryPointsOf(graph *callGraph) []string {
+	ep, _ := getEntryPoints(graph, nil)
+	return ep
+}
+
+func sortSyscallsByID(syscalls []SystemCall) {
+	sort.Slice(syscalls, func(i, j int) bool {
+		return syscalls[i].ID < syscalls[j].ID
+	})
+}
+
+func TestExtractContext_Cancelled(t *testing.T) {
+	should := should.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := ExtractContext(ctx, NewDumpReader("../../test/systrac.dump"), WithArchitecture("amd64"))
+
+	should.BeEqual(context.Canceled, errors.Cause(err), "should stop extraction once context is done")
+}
+
+func TestExtractSyscalls_Cancelled(t *testing.T) {
+	should := should.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	graph := graphOf(map[string]symbolDefinition{"main.main": {syscallIDs: []uint16{1}}})
+
+	_, err := extractSyscalls(ctx, graph, []string{"main.main"}, amd64SystemCalls)
+
+	should.BeEqual(context.Canceled, err, "should stop walking once context is done")
+}
@@ -0,0 +1,307 @@
+package systract
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	syscallInstructionRegex string = "^(SYSCALL|SVC|SWI|ECALL|INT \\$0x80)\\b"
+	memoryOperandRegex      string = "^(-?(0x[0-9a-fA-F]+|[0-9]+))?\\(([A-Z][A-Z0-9]*)\\)$"
+	registerOperandRegex    string = "^[A-Z][A-Z0-9]*$"
+)
+
+// abiSpec describes where values are placed when system calls are dispatched
+// and when go functions are called on a given architecture.
+type abiSpec struct {
+	// syscallRegister holds the syscall number when the syscall instruction is executed.
+	syscallRegister string
+	// registerArgs are the integer argument registers used by ABIInternal (Go 1.17+).
+	registerArgs []string
+	// stackBase, stackOffset and stackSlot define the stack locations
+	// of arguments for ABI0 calls.
+	stackBase   string
+	stackOffset int64
+	stackSlot   int64
+}
+
+var abiSpecs = map[string]abiSpec{
+	"amd64": {
+		syscallRegister: "AX",
+		registerArgs:    []string{"AX", "BX", "CX", "DI", "SI", "R8", "R9", "R10", "R11"},
+		stackBase:       "SP", stackOffset: 0, stackSlot: 8,
+	},
+	"386": {
+		syscallRegister: "AX",
+		stackBase:       "SP", stackOffset: 0, stackSlot: 4,
+	},
+	"arm64": {
+		syscallRegister: "R8",
+		registerArgs:    []string{"R0", "R1", "R2", "R3", "R4", "R5", "R6", "R7"},
+		stackBase:       "RSP", stackOffset: 8, stackSlot: 8,
+	},
+	"arm": {
+		syscallRegister: "R7",
+		stackBase:       "R13", stackOffset: 4, stackSlot: 4,
+	},
+	"ppc64le": {
+		syscallRegister: "R0",
+		registerArgs:    []string{"R3", "R4", "R5", "R6", "R7", "R8", "R9", "R10"},
+		stackBase:       "R1", stackOffset: 32, stackSlot: 8,
+	},
+	"s390x": {
+		syscallRegister: "R1",
+		stackBase:       "R15", stackOffset: 8, stackSlot: 8,
+	},
+	"riscv64": {
+		syscallRegister: "A7",
+		registerArgs:    []string{"A0", "A1", "A2", "A3", "A4", "A5", "A6", "A7"},
+		stackBase:       "SP", stackOffset: 8, stackSlot: 8,
+	},
+}
+
+// registerTracker follows constant values as they are moved across registers
+// and stack slots within a function, so the syscall number can be resolved
+// at the point in which the system call is dispatched.
+type registerTracker struct {
+	abi    abiSpec
+	values map[string]uint64
+	// stored holds the stack locations written since the last call.
+	stored map[string]bool
+}
+
+func newRegisterTracker(abi abiSpec) *registerTracker {
+	return &registerTracker{
+		abi:    abi,
+		values: make(map[string]uint64),
+		stored: make(map[string]bool),
+	}
+}
+
+// syscallRegisterValue returns the value of the register that holds
+// the syscall number when a syscall instruction is executed.
+func (t *registerTracker) syscallRegisterValue() (uint64, bool) {
+	v, found := t.values[t.abi.syscallRegister]
+	return v, found
+}
+
+// argument returns the value of the argument at position index
+// for the function being called.
+//
+// ABI0 calls receive arguments on the stack, whilst ABIInternal calls
+// receive them in registers. Stack locations only take precedence
+// when they were written since the last call, as ABIInternal callers
+// do not set them.
+func (t *registerTracker) argument(index int) (uint64, bool) {
+	slot := stackLocation(t.abi.stackOffset+int64(index)*t.abi.stackSlot, t.abi.stackBase)
+	if t.stored[slot] {
+		v, found := t.values[slot]
+		return v, found
+	}
+
+	if index < len(t.abi.registerArgs) {
+		v, found := t.values[t.abi.registerArgs[index]]
+		return v, found
+	}
+
+	return 0, false
+}
+
+// track updates the known values based on the effects of instruction.
+func (t *registerTracker) track(instruction string) {
+	op, operands := splitInstruction(instruction)
+	if op == "" {
+		return
+	}
+
+	switch {
+	case op == "CALL" || op == "BL":
+		t.reset()
+
+	case strings.HasPrefix(op, "CMP"), strings.HasPrefix(op, "TEST"),
+		strings.HasPrefix(op, "J"), nonWritingOperations[op]:
+		// instructions that do not write into registers or memory.
+
+	case strings.HasPrefix(op, "MOV") && len(operands) == 2:
+		if v, found := t.valueOf(operands[0]); found {
+			t.set(operands[1], v)
+			return
+		}
+		t.unset(operands[1])
+
+	case strings.HasPrefix(op, "XOR") && len(operands) == 2 && operands[0] == operands[1]:
+		t.set(operands[1], 0)
+
+	case strings.HasPrefix(op, "LEA") && len(operands) == 2:
+		if v, found := t.effectiveAddress(operands[0]); found {
+			t.set(operands[1], v)
+			return
+		}
+		t.unset(operands[1])
+
+	default:
+		if len(operands) > 0 {
+			t.unset(operands[len(operands)-1])
+		}
+	}
+}
+
+func (t *registerTracker) reset() {
+	t.values = make(map[string]uint64)
+	t.stored = make(map[string]bool)
+}
+
+func (t *registerTracker) set(operand string, value uint64) {
+	if location, isStack := normaliseLocation(operand); location != "" {
+		t.values[location] = value
+		if isStack {
+			t.stored[location] = true
+		}
+	}
+}
+
+func (t *registerTracker) unset(operand string) {
+	if location, isStack := normaliseLocation(operand); location != "" {
+		delete(t.values, location)
+		if isStack {
+			t.stored[location] = true
+		}
+	}
+}
+
+func (t *registerTracker) valueOf(operand string) (uint64, bool) {
+	if strings.HasPrefix(operand, "$") {
+		return parseImmediate(strings.TrimPrefix(operand, "$"))
+	}
+	if operand == "ZR" {
+		return 0, true
+	}
+
+	location, _ := normaliseLocation(operand)
+	v, found := t.values[location]
+	return v, found
+}
+
+// effectiveAddress resolves LEA operands such as 0x27 or 0x1(AX).
+func (t *registerTracker) effectiveAddress(operand string) (uint64, bool) {
+	if v, found := parseImmediate(operand); found {
+		return v, true
+	}
+
+	captures := memoryOperand.FindStringSubmatch(operand)
+	if captures == nil {
+		return 0, false
+	}
+
+	base, found := t.values[captures[3]]
+	if !found {
+		return 0, false
+	}
+
+	offset, _ := strconv.ParseInt(captures[1], 0, 64)
+	return base + uint64(offset), true
+}
+
+// nonWritingOperations are branch and control instructions that
+// do not change the value of any of their operands.
+var nonWritingOperations = map[string]bool{
+	"B": true, "BEQ": true, "BNE": true, "BLT": true, "BLE": true, "BGT": true,
+	"BGE": true, "BLO": true, "BLS": true, "BHI": true, "BHS": true, "BMI": true,
+	"BPL": true, "BVS": true, "BVC": true, "CBZ": true, "CBNZ": true, "CBZW": true,
+	"CBNZW": true, "TBZ": true, "TBNZ": true, "BT": true, "BTL": true, "BTQ": true,
+	"RET": true, "NOP": true, "INT": true, "UD2": true, "PAUSE": true,
+}
+
+var (
+	syscallInstruction = regexp.MustCompile(syscallInstructionRegex)
+	memoryOperand      = regexp.MustCompile(memoryOperandRegex)
+	registerOperand    = regexp.MustCompile(registerOperandRegex)
+)
+
+func isSyscallInstruction(instruction string) bool {
+	return syscallInstruction.MatchString(instruction)
+}
+
+// getInstruction returns the disassembled instruction from an objdump line,
+// which is formed by tab separated fields: file:line, address, encoding,
+// instruction and optionally relocations.
+func getInstruction(assemblyLine string) string {
+	fields := make([]string, 0, 5)
+	for _, field := range strings.Split(assemblyLine, "\t") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	if len(fields) >= 4 {
+		return fields[3]
+	}
+	if len(fields) > 0 {
+		return fields[len(fields)-1]
+	}
+
+	return ""
+}
+
+// splitInstruction splits an instruction into its operation and operands,
+// ignoring commas within parenthesis.
+func splitInstruction(instruction string) (string, []string) {
+	parts := strings.SplitN(strings.TrimSpace(instruction), " ", 2)
+	op := parts[0]
+	if len(parts) == 1 {
+		return op, nil
+	}
+
+	operands := make([]string, 0, 3)
+	depth, start := 0, 0
+	args := parts[1]
+	for i, c := range args {
+		switch c {
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				operands = append(operands, strings.TrimSpace(args[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	operands = append(operands, strings.TrimSpace(args[start:]))
+
+	return op, operands
+}
+
+// normaliseLocation returns a canonical name for registers and
+// stack locations, so 0(SP) and 0x0(SP) are tracked as the same slot.
+func normaliseLocation(operand string) (location string, isStack bool) {
+	if registerOperand.MatchString(operand) {
+		return operand, false
+	}
+
+	captures := memoryOperand.FindStringSubmatch(operand)
+	if captures == nil {
+		return "", false
+	}
+
+	offset, _ := strconv.ParseInt(captures[1], 0, 64)
+	return stackLocation(offset, captures[3]), true
+}
+
+func stackLocation(offset int64, base string) string {
+	return fmt.Sprintf("%d(%s)", offset, base)
+}
+
+func parseImmediate(value string) (uint64, bool) {
+	if n, err := strconv.ParseInt(value, 0, 64); err == nil {
+		return uint64(n), true
+	}
+	if n, err := strconv.ParseUint(value, 0, 64); err == nil {
+		return n, true
+	}
+
+	return 0, false
+}
@@ -0,0 +1,112 @@
+package systract
+
+import (
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestRegisterTracker_Argument(t *testing.T) {
+	assertThat := func(assumption, arch string, instructions []string, index int,
+		expected uint64, expectedFound bool) {
+		should := should.New(t)
+		tracker := newRegisterTracker(abiSpecs[arch])
+		for _, instruction := range instructions {
+			tracker.track(instruction)
+		}
+
+		actual, found := tracker.argument(index)
+
+		should.BeEqual(expectedFound, found, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should read ABI0 arguments from the stack", "amd64",
+		[]string{"MOVQ $0x7d, 0(SP)", "MOVQ $0x1, 0x8(SP)"}, 1, 1, true)
+	assertThat("should read ABIInternal arguments from registers", "amd64",
+		[]string{"MOVL $0x7d, AX", "MOVL $0x1, BX"}, 1, 1, true)
+	assertThat("should prefer stack slots written since last call", "amd64",
+		[]string{"MOVQ $0x7d, 0(SP)", "XORL AX, AX"}, 0, 0x7d, true)
+	assertThat("should not resolve unknown stack slots", "amd64",
+		[]string{"MOVQ $0x7d, AX", "MOVQ 0x40(SP), CX", "MOVQ CX, 0(SP)"}, 0, 0, false)
+	assertThat("should read arm64 ABI0 arguments", "arm64",
+		[]string{"MOVD $63, R0", "MOVD R0, 8(RSP)"}, 0, 63, true)
+	assertThat("should handle arm64 zero register", "arm64",
+		[]string{"MOVD ZR, 8(RSP)"}, 0, 0, true)
+	assertThat("should clear values after calls", "amd64",
+		[]string{"MOVQ $0x7d, 0(SP)", "CALL runtime.entersyscall(SB)"}, 0, 0, false)
+}
+
+func TestRegisterTracker_SyscallRegisterValue(t *testing.T) {
+	assertThat := func(assumption, arch string, instructions []string,
+		expected uint64, expectedFound bool) {
+		should := should.New(t)
+		tracker := newRegisterTracker(abiSpecs[arch])
+		for _, instruction := range instructions {
+			tracker.track(instruction)
+		}
+
+		actual, found := tracker.syscallRegisterValue()
+
+		should.BeEqual(expectedFound, found, assumption)
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should read amd64 syscall number from AX", "amd64",
+		[]string{"MOVL $0xe7, AX", "MOVL 0x8(SP), DI"}, 0xe7, true)
+	assertThat("should read arm64 syscall number from R8", "arm64",
+		[]string{"MOVD $93, R8", "MOVW 8(RSP), R0"}, 93, true)
+	assertThat("should read 386 syscall number from AX", "386",
+		[]string{"MOVL $0xfc, AX"}, 0xfc, true)
+	assertThat("should not resolve overwritten registers", "amd64",
+		[]string{"MOVL $0xe7, AX", "ANDL $0x1, AX"}, 0, false)
+	assertThat("should ignore comparisons", "amd64",
+		[]string{"MOVL $0xe7, AX", "CMPQ AX, $0x1", "JNE 0x1234"}, 0xe7, true)
+}
+
+func TestSplitInstruction(t *testing.T) {
+	assertThat := func(assumption, instruction, expectedOp string, expectedOperands []string) {
+		should := should.New(t)
+
+		op, operands := splitInstruction(instruction)
+
+		should.BeEqual(expectedOp, op, assumption)
+		should.BeEqual(expectedOperands, operands, assumption)
+	}
+
+	assertThat("should split operation without operands", "SYSCALL", "SYSCALL", nil)
+	assertThat("should split operands", "MOVQ $0x7d, 0(SP)", "MOVQ", []string{"$0x7d", "0(SP)"})
+	assertThat("should not split within parenthesis", "LDP 16(RSP), (R0, R1)", "LDP", []string{"16(RSP)", "(R0, R1)"})
+}
+
+func TestNormaliseLocation(t *testing.T) {
+	assertThat := func(assumption, operand, expected string, expectedStack bool) {
+		should := should.New(t)
+
+		actual, isStack := normaliseLocation(operand)
+
+		should.BeEqual(expected, actual, assumption)
+		should.BeEqual(expectedStack, isStack, assumption)
+	}
+
+	assertThat("should keep register names", "AX", "AX", false)
+	assertThat("should normalise hex offsets", "0x8(SP)", "8(SP)", true)
+	assertThat("should normalise missing offsets", "(SP)", "0(SP)", true)
+	assertThat("should handle negative offsets", "-0x10(BP)", "-16(BP)", true)
+	assertThat("should ignore indexed operands", "0(AX)(CX*8)", "", false)
+}
+
+func TestGetInstruction(t *testing.T) {
+	assertThat := func(assumption, assemblyLine, expected string) {
+		should := should.New(t)
+
+		actual := getInstruction(assemblyLine)
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should extract instruction from objdump lines",
+		"  zsyscall_linux_amd64.go:442	0x48bd75		48c704247d000000	MOVQ $0x7d, 0(SP)				", "MOVQ $0x7d, 0(SP)")
+	assertThat("should ignore relocations",
+		"  systrac.go:37		0x5c22			e800000000		CALL 0x5c27		[1:5]R_CALL:runtime.makemap_small	", "CALL 0x5c27")
+}
@@ -0,0 +1,132 @@
+package systract
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// WarningKind defines the condition a Warning was raised for.
+type WarningKind string
+
+const (
+	// WarningUnresolvedSyscall is raised when the number of a system call cannot be resolved.
+	WarningUnresolvedSyscall WarningKind = "unresolved-syscall"
+	// WarningUnknownSyscall is raised when the number of a system call is not in the syscall table.
+	WarningUnknownSyscall WarningKind = "unknown-syscall"
+	// WarningUnresolvedCall is raised when the target of a call is not within any function in the dump.
+	WarningUnresolvedCall WarningKind = "unresolved-call"
+	// WarningSource is raised when information cannot be read or detected from the source.
+	// It is informational, so it does not fail extractions in strict mode.
+	WarningSource WarningKind = "source"
+	// WarningEntryPoints is raised when none of the default entry points are found, such as in
+	// shared libraries. It is informational, so it does not fail extractions in strict mode.
+	WarningEntryPoints WarningKind = "entry-points"
+)
+
+// Informational checks whether warnings of kind only describe what the source
+// provides, instead of a system call or call target that was dropped.
+func (k WarningKind) Informational() bool {
+	return k == WarningSource || k == WarningEntryPoints
+}
+
+// Warning describes a condition in which system calls may have been missed.
+type Warning struct {
+	Kind    WarningKind `json:"kind" yaml:"kind"`
+	Message string      `json:"message" yaml:"message"`
+	// Symbol, Location and Address are only set for warnings raised by an instruction,
+	// with Location in the file:line format.
+	Symbol   string `json:"symbol,omitempty" yaml:"symbol,omitempty"`
+	Location string `json:"location,omitempty" yaml:"location,omitempty"`
+	Address  string `json:"address,omitempty" yaml:"address,omitempty"`
+}
+
+func (w Warning) String() string {
+	var b strings.Builder
+	b.WriteString(w.Message)
+	if w.Symbol != "" {
+		fmt.Fprintf(&b, " in %s", w.Symbol)
+	}
+	if w.Location != "" {
+		fmt.Fprintf(&b, " at %s", w.Location)
+	}
+	if w.Address != "" {
+		fmt.Fprintf(&b, " (%s)", w.Address)
+	}
+
+	return b.String()
+}
+
+// WarningsError is returned by extractions in strict mode when warnings that are
+// not informational are raised.
+type WarningsError struct {
+	Warnings []Warning
+}
+
+func (e *WarningsError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d warnings raised in strict mode:", len(e.Warnings))
+	for _, w := range e.Warnings {
+		fmt.Fprintf(&b, "\n    %s", w)
+	}
+
+	return b.String()
+}
+
+// getLineLocation returns the file:line and address of the instruction in assemblyLine.
+// The location is empty for instructions without line information, such as the ones of C code.
+func getLineLocation(assemblyLine string) (location, address string) {
+	fields := make([]string, 0, 5)
+	for _, field := range strings.Split(assemblyLine, "\t") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, field)
+		}
+	}
+
+	if len(fields) < 4 {
+		return "", ""
+	}
+
+	location = fields[0]
+	if i := strings.LastIndex(location, ":"); i >= 0 {
+		if line, err := strconv.Atoi(location[i+1:]); err == nil && line <= 0 {
+			location = ""
+		}
+	}
+
+	return location, fields[1]
+}
+
+// getStrictWarnings returns the warnings which fail extractions in strict mode.
+func getStrictWarnings(warnings []Warning) []Warning {
+	var strict []Warning
+	for _, w := range warnings {
+		if !w.Kind.Informational() {
+			strict = append(strict, w)
+		}
+	}
+
+	return strict
+}
+
+// getReachableWarnings returns the warnings of the symbols reachable from roots, in the order
+// the symbols were found. The syscall numbers of wrappers are defined by their callers,
+// so the unresolved syscalls within the wrappers themselves are ignored.
+func getReachableWarnings(graph *callGraph, roots []string, wrappers map[string]SyscallWrapper) []Warning {
+	var warnings []Warning
+	for i, reachable := range reachableSymbols(graph, roots) {
+		if !reachable {
+			continue
+		}
+
+		_, isWrapper := wrappers[strings.TrimSuffix(graph.names[i], ".abi0")]
+		for _, w := range graph.warnings[int32(i)] {
+			if isWrapper && w.Kind == WarningUnresolvedSyscall {
+				continue
+			}
+			warnings = append(warnings, w)
+		}
+	}
+
+	return warnings
+}
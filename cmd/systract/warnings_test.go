@@ -0,0 +1,138 @@
+package systract
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pjbgf/go-test/should"
+)
+
+func TestDumpParser_Warnings(t *testing.T) {
+	should := should.New(t)
+	dump := `TEXT main.main(SB) main.go
+  main.go:1	0x10	488b442408	MOVQ 0x8(SP), AX
+  main.go:2	0x15	0f05	SYSCALL
+  main.go:3	0x17	b802100000	MOVL $0x1002, AX
+  main.go:4	0x1c	0f05	SYSCALL
+  main.go:5	0x1e	e800000000	CALL 0x48
+  main.go:6	0x23	e800000000	CALL 0xdead
+
+TEXT runtime.duffzero(SB) duff_amd64.s
+  duff_amd64.s:8	0x40	440f1107	MOVUPS X15, 0(DI)
+  duff_amd64.s:9	0x48	440f117f10	MOVUPS X15, 0x10(DI)
+  duff_amd64.s:10	0x4d	c3	RET
+
+`
+	graph, err := newAmd64Parser(2).parse(context.Background(), strings.NewReader(dump))
+
+	should.NotError(err, "should parse dump")
+	should.BeEqual([]string{"runtime.duffzero"}, (&CallGraph{graph: graph}).Calls("main.main"),
+		"should link calls into addresses to the symbols that contain them")
+	should.BeEqual([]Warning{
+		{Kind: WarningUnresolvedSyscall, Message: "could not resolve the system call number",
+			Symbol: "main.main", Location: "main.go:2", Address: "0x15"},
+		{Kind: WarningUnknownSyscall, Message: "unknown system call ID 0x1002",
+			Symbol: "main.main", Location: "main.go:4", Address: "0x1c"},
+		{Kind: WarningUnresolvedCall, Message: "could not resolve the call target 0xdead",
+			Symbol: "main.main", Location: "main.go:6", Address: "0x23"},
+	}, getReachableWarnings(graph, []string{"main.main"}, nil), "should warn about dropped syscalls and calls")
+}
+
+func TestDumpParser_LinkerStubs(t *testing.T) {
+	should := should.New(t)
+	dump := `TEXT _cgo_Cfunc_getpid(SB) 
+  :0	0x4e87c0	e800000000	CALL 0x4020b0
+  :0	0x4e87c5	e800000000	CALL 0x10
+
+`
+	parser := newAmd64Parser(1)
+	parser.stubs = []AddressRange{{Start: 0x402020, End: 0x402360}}
+
+	graph, err := parser.parse(context.Background(), strings.NewReader(dump))
+
+	should.NotError(err, "should parse dump")
+	should.BeEqual([]Warning{
+		{Kind: WarningUnresolvedCall, Message: "could not resolve the call target 0x10",
+			Symbol: "_cgo_Cfunc_getpid", Address: "0x4e87c5"},
+	}, getReachableWarnings(graph, []string{"_cgo_Cfunc_getpid"}, nil),
+		"should ignore calls into stubs and leave location empty without line information")
+}
+
+func TestGetLineLocation(t *testing.T) {
+	assertThat := func(assumption, line, expectedLocation, expectedAddress string) {
+		should := should.New(t)
+
+		location, address := getLineLocation(line)
+
+		should.BeEqual(expectedLocation, location, assumption)
+		should.BeEqual(expectedAddress, address, assumption)
+	}
+
+	assertThat("should return file and line", "  main.go:6\t0x23\te800000000\tCALL 0xdead", "main.go:6", "0x23")
+	assertThat("should ignore line zero", "  :0\t0x23\te800000000\tCALL 0xdead", "", "0x23")
+	assertThat("should ignore negative lines", "  :-35\t0x23\te800000000\tCALL 0xdead", "", "0x23")
+	assertThat("should return nothing for lines without instruction", "TEXT main.main(SB) main.go", "", "")
+}
+
+func TestGetStrictWarnings(t *testing.T) {
+	should := should.New(t)
+	unresolved := Warning{Kind: WarningUnresolvedCall, Message: "could not resolve the call target 0xdead"}
+
+	actual := getStrictWarnings([]Warning{
+		{Kind: WarningSource, Message: "could not detect the go version"},
+		unresolved,
+		{Kind: WarningEntryPoints, Message: "none of the default entry points were found"},
+	})
+
+	should.BeEqual([]Warning{unresolved}, actual, "should ignore informational warnings")
+}
+
+func TestGetReachableWarnings(t *testing.T) {
+	assertThat := func(assumption string, roots []string, expected []Warning) {
+		should := should.New(t)
+		graph := graphOf(map[string]symbolDefinition{
+			"main.main": {subCalls: []string{"syscall.Syscall"}},
+			"main.unused": {warnings: []Warning{
+				{Kind: WarningUnknownSyscall, Message: "unknown system call ID 0x1002"}}},
+			"syscall.Syscall": {warnings: []Warning{
+				{Kind: WarningUnresolvedSyscall, Message: "could not resolve the system call number"}}},
+		})
+
+		actual := getReachableWarnings(graph, roots, DefaultCatalog.index())
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should ignore unresolved syscalls within wrappers", []string{"main.main"}, nil)
+	assertThat("should return warnings of reachable symbols", []string{"main.main", "main.unused"}, []Warning{
+		{Kind: WarningUnknownSyscall, Message: "unknown system call ID 0x1002", Symbol: "main.unused"}})
+}
+
+func TestWarning_String(t *testing.T) {
+	assertThat := func(assumption string, warning Warning, expected string) {
+		should := should.New(t)
+
+		actual := warning.String()
+
+		should.BeEqual(expected, actual, assumption)
+	}
+
+	assertThat("should return message of warnings without location",
+		Warning{Kind: WarningSource, Message: "could not detect the go version"}, "could not detect the go version")
+	assertThat("should include symbol, location and address",
+		Warning{Kind: WarningUnknownSyscall, Message: "unknown system call ID 0x1002",
+			Symbol: "main.main", Location: "main.go:4", Address: "0x1c"},
+		"unknown system call ID 0x1002 in main.main at main.go:4 (0x1c)")
+}
+
+func TestWarningsError(t *testing.T) {
+	should := should.New(t)
+	err := &WarningsError{Warnings: []Warning{
+		{Message: "could not detect the go version"},
+		{Message: "could not resolve the call target 0xdead", Symbol: "main.main"},
+	}}
+
+	should.BeEqual("2 warnings raised in strict mode:\n    could not detect the go version\n"+
+		"    could not resolve the call target 0xdead in main.main", err.Error(), "should list all warnings")
+}